@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -15,16 +15,20 @@ var (
 	addTitle   string
 	addContent string
 	addTags    string
+	addEncrypt bool
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <category> [title] [content]",
+	Use:   "add [category] [title] [content]",
 	Short: "Add a new note",
+	Long: `Add a new note. The category may be left out when the config file,
+profile or BRAINDUMP_CATEGORY sets a default category.`,
 	Example: `  braindump add api-creds --title "Stripe Key" --content "sk_test_..."
   braindump add api-creds "Stripe Key" "sk_test_..."
   echo "sk_test_..." | braindump add api-creds --title "Stripe Key"
-  braindump add api-creds --title "Stripe" --content "..." --tags "stripe,payment"`,
-	Args: cobra.MinimumNArgs(1),
+  braindump add api-creds --title "Stripe" --content "..." --tags "stripe,payment"
+  braindump add --title "Idea" --content "..."   # default category
+  braindump add api-creds --title "Stripe" --content "sk_test_..." --encrypt`,
 	RunE: runAdd,
 }
 
@@ -33,10 +37,17 @@ func init() {
 	addCmd.Flags().StringVar(&addTitle, "title", "", "note title")
 	addCmd.Flags().StringVar(&addContent, "content", "", "note content")
 	addCmd.Flags().StringVar(&addTags, "tags", "", "comma-separated tags")
+	addCmd.Flags().BoolVar(&addEncrypt, "encrypt", false, "encrypt the content at rest (needs BRAINDUMP_KEY or a key file)")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
-	category := args[0]
+	category := settings.Category
+	if len(args) > 0 {
+		category = args[0]
+	}
+	if category == "" {
+		return &usageError{fmt.Errorf("category is required (provide as argument or set a default category in the config)")}
+	}
 
 	title := addTitle
 	content := addContent
@@ -77,21 +88,18 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	note := models.NewNote(category, title, content, tags)
+	if addEncrypt {
+		note.Metadata[storage.EncryptedKey] = "true"
+	}
 
-	if err := store.Add(note); err != nil {
+	if err := client.Add(cmd.Context(), note); err != nil {
 		return fmt.Errorf("failed to add note: %w", err)
 	}
-
-	if formatFlag == "json" {
-		return outputJSON(note)
+	if err := protect(cmd, note); err != nil {
+		return err
 	}
 
-	fmt.Printf("✓ Added note to %s: \"%s\" (id: %s)\n", category, title, note.ID[:8])
-	return nil
-}
-
-func outputJSON(v interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(v)
+	return render(output{kind: kindNote, data: note, text: func() {
+		fmt.Printf("✓ Added note to %s: \"%s\" (id: %s)\n", category, title, idLabel(note))
+	}})
 }
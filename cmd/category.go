@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var categoryCmd = &cobra.Command{
+	Use:   "category",
+	Short: "Rename or merge categories",
+}
+
+var categoryRenameCmd = &cobra.Command{
+	Use:     "rename <old> <new>",
+	Short:   "Rename a category and its subcategories",
+	Example: `  braindump category rename api-creds credentials`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runCategoryRename,
+}
+
+var categoryMergeCmd = &cobra.Command{
+	Use:     "merge <src> <dst>",
+	Short:   "Move every note from one category into another",
+	Example: `  braindump category merge stripe payments`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runCategoryMerge,
+}
+
+var moveCmd = &cobra.Command{
+	Use:     "move <id> <category>",
+	Short:   "Move a note to another category",
+	Example: `  braindump move a1b2c3d4 credentials`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runMove,
+}
+
+func init() {
+	rootCmd.AddCommand(categoryCmd)
+	rootCmd.AddCommand(moveCmd)
+	categoryCmd.AddCommand(categoryRenameCmd)
+	categoryCmd.AddCommand(categoryMergeCmd)
+}
+
+func runCategoryRename(cmd *cobra.Command, args []string) error {
+	if err := client.RenameCategory(cmd.Context(), args[0], args[1]); err != nil {
+		return fmt.Errorf("failed to rename category: %w", err)
+	}
+
+	change := categoryChange{From: storage.CleanCategory(args[0]), To: storage.CleanCategory(args[1])}
+	return render(output{kind: kindCategoryChange, data: change, text: func() {
+		fmt.Printf("✓ Renamed category %s to %s\n", change.From, change.To)
+	}})
+}
+
+func runCategoryMerge(cmd *cobra.Command, args []string) error {
+	if err := client.MergeCategory(cmd.Context(), args[0], args[1]); err != nil {
+		return fmt.Errorf("failed to merge category: %w", err)
+	}
+
+	change := categoryChange{From: storage.CleanCategory(args[0]), To: storage.CleanCategory(args[1])}
+	return render(output{kind: kindCategoryChange, data: change, text: func() {
+		fmt.Printf("✓ Merged category %s into %s\n", change.From, change.To)
+	}})
+}
+
+func runMove(cmd *cobra.Command, args []string) error {
+	note, err := client.Resolve(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	moved, err := client.Move(cmd.Context(), note.ID, args[1])
+	if err != nil {
+		return fmt.Errorf("failed to move note: %w", err)
+	}
+	if err := protect(cmd, moved); err != nil {
+		return err
+	}
+
+	return render(output{kind: kindNote, data: moved, text: func() {
+		fmt.Printf("✓ Moved note \"%s\" (id: %s) from %s to %s\n", note.Title, idLabel(moved), note.Category, moved.Category)
+	}})
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MohGanji/braindump/pkg/config"
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/secrets"
+	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change the config file",
+	Long: `Read and change the config file, by default ~/.config/braindump/config.yaml
+($XDG_CONFIG_HOME and $BRAINDUMP_CONFIG move it).
+
+Keys are dotted paths: store, format, category, tags.preserve_case,
+tags.aliases.<alias>, profile (the default profile) and the same keys under
+profiles.<name>.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:     "get <key>",
+	Short:   "Print a config value",
+	Example: `  braindump config get profiles.work.store`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a config value; an empty value removes it",
+	Example: `  braindump config set profiles.work.store ~/notes/work
+  braindump config set profile work
+  braindump config set format ""`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every config value",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configListCmd)
+}
+
+// isConfigCommand reports whether cmd is config or one of its subcommands.
+func isConfigCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd {
+			return true
+		}
+	}
+	return false
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return &usageError{err}
+	}
+
+	entry := configEntry{Key: args[0], Value: value}
+	return render(output{kind: kindConfig, data: configEntries{entry}, text: func() {
+		fmt.Println(value)
+	}})
+}
+
+// configReadOnly reports whether read-only mode is on: with --read-only,
+// BRAINDUMP_READ_ONLY, or read_only in the selected profile if the config
+// resolves.
+func configReadOnly(cmd *cobra.Command) bool {
+	if readOnly || config.Env().ReadOnly {
+		return true
+	}
+	return loadSettings(cmd) == nil && settings.ReadOnly
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	// Read-only mode would otherwise be one config change from turned off
+	if configReadOnly(cmd) {
+		return fmt.Errorf("%w: read-only mode is on, the config can't be changed", storage.ErrForbidden)
+	}
+
+	path := config.Path()
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return &usageError{err}
+	}
+	if err := checkConfig(cfg); err != nil {
+		return &usageError{err}
+	}
+	if err := cfg.Save(path); err != nil {
+		return err
+	}
+
+	entry := configEntry{Key: key, Value: value}
+	return render(output{kind: kindConfig, data: configEntries{entry}, text: func() {
+		if value == "" {
+			fmt.Printf("✓ Removed %s from %s\n", key, path)
+			return
+		}
+		fmt.Printf("✓ Set %s = %s in %s\n", key, value, path)
+	}})
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return err
+	}
+
+	list, err := cfg.List()
+	if err != nil {
+		return err
+	}
+
+	entries := make(configEntries, len(list))
+	for i, kv := range list {
+		entries[i] = configEntry{Key: kv[0], Value: kv[1]}
+	}
+
+	return render(output{kind: kindConfig, data: entries, text: func() {
+		for _, e := range entries {
+			fmt.Printf("%s=%s\n", e.Key, e.Value)
+		}
+	}})
+}
+
+// checkConfig rejects values that would make every later command fail.
+func checkConfig(cfg *config.Config) error {
+	all := map[string]config.Settings{"": cfg.Settings}
+	for name, p := range cfg.Profiles {
+		all["profiles."+name+"."] = p
+	}
+	for prefix, s := range all {
+		if s.Format != "" {
+			if _, err := lookupFormatter(s.Format); err != nil {
+				return fmt.Errorf("invalid %sformat: %w", prefix, err)
+			}
+			if s.Format == "hook" {
+				return fmt.Errorf("invalid %sformat: the hook format is only supported by the context command", prefix)
+			}
+		}
+		if _, err := secrets.ParsePolicy(s.Secrets); err != nil {
+			return fmt.Errorf("invalid %ssecrets: %w", prefix, err)
+		}
+		if _, err := injection.ParseMode(s.Injection); err != nil {
+			return fmt.Errorf("invalid %sinjection: %w", prefix, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextCwd    string
+	contextRepo   string
+	contextBudget int
+	contextRecent int
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Print a memory primer for the start of an agent session",
+	Long: `Print a compact primer of what braindump knows, for agent harnesses to
+inject when a session starts: notes tagged "pinned" and notes tagged with the
+current repository's name in full, then the titles of recently updated notes.
+
+The repository is the nearest directory above --cwd containing .git. The
+primer is capped at --budget estimated tokens.
+
+With --format hook the primer is wrapped in a session-start hook response
+({"hookSpecificOutput": {"hookEventName": "SessionStart", "additionalContext": ...}}).`,
+	Example: `  braindump context
+  braindump context --cwd . --format hook
+  braindump context --repo billing --budget 500`,
+	Args: cobra.NoArgs,
+	RunE: runContext,
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.Flags().StringVar(&contextCwd, "cwd", ".", "directory to detect the repository from")
+	contextCmd.Flags().StringVar(&contextRepo, "repo", "", "repository name (default: detected from --cwd)")
+	contextCmd.Flags().IntVar(&contextBudget, "budget", 1000, "maximum estimated tokens")
+	contextCmd.Flags().IntVar(&contextRecent, "recent", 10, "number of recently updated notes to list")
+	addRevealFlag(contextCmd)
+	addGuardFlag(contextCmd)
+}
+
+func runContext(cmd *cobra.Command, args []string) error {
+	mode, err := guardMode(cmd)
+	if err != nil {
+		return err
+	}
+
+	repo := contextRepo
+	if repo == "" {
+		repo = repoName(contextCwd)
+	}
+
+	primer, err := client.Primer(cmd.Context(), braindump.PrimerOptions{
+		Repo:   repo,
+		Budget: contextBudget,
+		Recent: contextRecent,
+		Reveal: revealFlag,
+		Guard:  mode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build context: %w", err)
+	}
+
+	return render(output{kind: kindPrimer, data: primerOutput{primer}, text: func() {
+		fmt.Print(primer.Text)
+	}})
+}
+
+// repoName returns the name of the directory holding .git at or above dir,
+// or the name of dir itself outside a repository.
+func repoName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return filepath.Base(d)
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if filepath.Dir(abs) == abs {
+		return ""
+	}
+	return filepath.Base(abs)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/secrets"
+	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// Process exit codes. Agents can branch on these instead of parsing messages.
+const (
+	ExitOK              = 0
+	ExitError           = 1
+	ExitUsage           = 2
+	ExitNotFound        = 3
+	ExitAmbiguous       = 4
+	ExitConflict        = 5
+	ExitInvalidCategory = 6
+	ExitNoKey           = 7
+	ExitSecret          = 8
+	ExitForbidden       = 9
+)
+
+// usageError marks errors caused by invalid arguments or flags.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// errorInfo classifies an error into a stable code and exit status.
+func errorInfo(err error) (string, int) {
+	var usage *usageError
+	switch {
+	case errors.As(err, &usage):
+		return "usage", ExitUsage
+	case errors.Is(err, storage.ErrInvalidQuery):
+		return "invalid_query", ExitUsage
+	case errors.Is(err, storage.ErrNotFound):
+		return "not_found", ExitNotFound
+	case errors.Is(err, storage.ErrAmbiguous):
+		return "ambiguous", ExitAmbiguous
+	case errors.Is(err, storage.ErrConflict):
+		return "conflict", ExitConflict
+	case errors.Is(err, storage.ErrInvalidCategory):
+		return "invalid_category", ExitInvalidCategory
+	case errors.Is(err, storage.ErrNoKey):
+		return "no_key", ExitNoKey
+	case errors.Is(err, storage.ErrBadKey):
+		return "bad_key", ExitNoKey
+	case errors.Is(err, storage.ErrForbidden):
+		return "forbidden", ExitForbidden
+	case errors.Is(err, secrets.ErrSecret):
+		return "secret", ExitSecret
+	default:
+		return "error", ExitError
+	}
+}
+
+// HandleError reports err on stderr, as JSON if a JSON format is set, and
+// returns the exit code for it.
+func HandleError(err error) int {
+	code, exitCode := errorInfo(err)
+
+	var ambiguous *braindump.AmbiguousError
+	errors.As(err, &ambiguous)
+
+	if formatFlag == "json" || formatFlag == "ndjson" {
+		type match struct {
+			ID       string `json:"id"`
+			Category string `json:"category"`
+			Title    string `json:"title"`
+		}
+		type jsonError struct {
+			Code     string  `json:"code"`
+			Message  string  `json:"message"`
+			ExitCode int     `json:"exit_code"`
+			Matches  []match `json:"matches,omitempty"`
+		}
+
+		out := jsonError{Code: code, Message: err.Error(), ExitCode: exitCode}
+		if ambiguous != nil {
+			for _, n := range ambiguous.Matches {
+				out.Matches = append(out.Matches, match{ID: n.ID, Category: n.Category, Title: n.Title})
+			}
+		}
+
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(struct {
+			Version int       `json:"version"`
+			Error   jsonError `json:"error"`
+		}{jsonVersion, out})
+		return exitCode
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if ambiguous != nil {
+		for _, n := range ambiguous.Matches {
+			fmt.Fprintf(os.Stderr, "  [%s] %s (id: %s)\n", n.Category, n.Title, idLabel(n))
+		}
+		if ambiguous.ByTitle {
+			fmt.Fprintln(os.Stderr, "Please specify by ID")
+		} else {
+			fmt.Fprintln(os.Stderr, "Please specify a longer ID prefix")
+		}
+	}
+	if exitCode == ExitUsage {
+		fmt.Fprintln(os.Stderr, "Run with --help for usage.")
+	}
+
+	return exitCode
+}
+
+// markUsageErrors makes argument and flag validation failures of cmd and its
+// subcommands exit with ExitUsage.
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return &usageError{err}
+	})
+
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(c *cobra.Command, a []string) error {
+			if err := args(c, a); err != nil {
+				return &usageError{err}
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/archive"
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOut      string
+	exportCategory string
+	exportTags     string
+	exportFormat   string
+
+	rulesOut      string
+	rulesCategory string
+	rulesTags     string
+	rulesSection  string
+	rulesBudget   int
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export notes to a portable archive",
+	Long: `Write notes to an archive that can be imported on another machine.
+
+Tar and zip archives hold each note as a markdown file with its full
+frontmatter, the audit history of the exported notes and a manifest with a
+schema version and a SHA-256 checksum of every file. JSONL holds one note per
+line and nothing else. The search index is not exported.
+
+The format is taken from the file name (.tar.gz, .tgz, .tar, .zip, .jsonl)
+unless --format is given; here --format selects the archive format, not the
+output format. Encrypted notes stay encrypted.`,
+	Example: `  braindump export --out backup.tar.gz
+  braindump export --out acme.zip --category clients/acme
+  braindump export --out conventions.jsonl --tag conventions`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var exportRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Write notes into a section of an agent rules file",
+	Long: `Render notes into a marked section of a markdown file such as AGENTS.md or
+CLAUDE.md, for agent harnesses that only read static rule files. The section
+sits between "<!-- braindump:begin <section> -->" and
+"<!-- braindump:end <section> -->" comments; a re-run replaces only that
+section and leaves the rest of the file alone. A file without the section
+gets it appended, and a missing file is created.
+
+Notes are rendered in full while they fit --budget estimated tokens, then
+listed by title. Sensitive and encrypted notes are always left out.`,
+	Example: `  braindump export rules --tag conventions --out AGENTS.md
+  braindump export rules --category billing --out CLAUDE.md --section billing --budget 500`,
+	Args: cobra.NoArgs,
+	RunE: runExportRules,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportRulesCmd)
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "file to write (required)")
+	exportCmd.Flags().StringVar(&exportCategory, "category", "", "export only this category and its subcategories")
+	exportCmd.Flags().StringVar(&exportTags, "tag", "", "export only notes with any of these tags (comma-separated)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "archive format (tar|zip|jsonl, default from the file name)")
+	exportCmd.MarkFlagRequired("out")
+
+	exportRulesCmd.Flags().StringVarP(&rulesOut, "out", "o", "", "markdown file to write the section into (required)")
+	exportRulesCmd.Flags().StringVar(&rulesCategory, "category", "", "include only this category and its subcategories")
+	exportRulesCmd.Flags().StringVar(&rulesTags, "tag", "", "include only notes with any of these tags (comma-separated)")
+	exportRulesCmd.Flags().StringVar(&rulesSection, "section", "braindump", "name of the section in the file")
+	exportRulesCmd.Flags().IntVar(&rulesBudget, "budget", 2000, "maximum estimated tokens of the section")
+	addGuardFlag(exportRulesCmd)
+	exportRulesCmd.MarkFlagRequired("out")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	format, compress := archive.FormatFor(exportOut)
+	if exportFormat != "" {
+		var err error
+		if format, err = archive.ParseFormat(exportFormat); err != nil {
+			return &usageError{err}
+		}
+		compress = format == archive.FormatTar && compress
+	}
+
+	var tags []string
+	if exportTags != "" {
+		tags = strings.Split(exportTags, ",")
+		for i, tag := range tags {
+			tags[i] = strings.TrimSpace(tag)
+		}
+	}
+
+	// Write next to the destination and rename, so a failed export doesn't
+	// leave a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(exportOut), ".braindump-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := client.Export(cmd.Context(), tmp, braindump.ExportOptions{
+		Format:   format,
+		Compress: compress,
+		Category: exportCategory,
+		Tags:     tags,
+	})
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to export notes: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), exportOut); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	result := exportResult{Path: exportOut, Format: format, Manifest: manifest}
+	return render(output{kind: kindExport, data: result, text: func() {
+		fmt.Printf("✓ Exported %d note(s) to %s\n", manifest.Notes, exportOut)
+	}})
+}
+
+func runExportRules(cmd *cobra.Command, args []string) error {
+	if err := braindump.ValidateSection(rulesSection); err != nil {
+		return &usageError{err}
+	}
+	guarded, err := guardMode(cmd)
+	if err != nil {
+		return err
+	}
+	if rulesBudget <= 0 {
+		return &usageError{fmt.Errorf("--budget must be positive")}
+	}
+
+	var tags []string
+	if rulesTags != "" {
+		tags = strings.Split(rulesTags, ",")
+		for i, tag := range tags {
+			tags[i] = strings.TrimSpace(tag)
+		}
+	}
+
+	rules, err := client.Rules(cmd.Context(), braindump.RulesOptions{
+		Section:       rulesSection,
+		Category:      rulesCategory,
+		Tags:          tags,
+		Budget:        rulesBudget,
+		SensitiveTags: settings.SensitiveTags,
+		Guard:         guarded,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render rules: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	old, err := os.ReadFile(rulesOut)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", rulesOut, err)
+	}
+	if info, err := os.Stat(rulesOut); err == nil {
+		mode = info.Mode().Perm()
+	}
+	doc, err := braindump.ReplaceSection(string(old), rules)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", rulesOut, err)
+	}
+
+	changed := doc != string(old)
+	if changed {
+		if err := writeFileAtomic(rulesOut, []byte(doc), mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rulesOut, err)
+		}
+	}
+
+	result := rulesResult{Path: rulesOut, Changed: changed, RulesResult: rules}
+	return render(output{kind: kindRules, data: result, text: func() {
+		if !changed {
+			fmt.Printf("✓ Section %q of %s is up to date (%d note(s))\n", rules.Section, rulesOut, len(rules.Notes))
+		} else {
+			fmt.Printf("✓ Wrote %d note(s) to section %q of %s (%d/%d tokens)\n", len(rules.Notes), rules.Section, rulesOut, rules.Tokens, rules.Budget)
+		}
+		if rules.Omitted > 0 {
+			fmt.Printf("  %d note(s) didn't fit the budget\n", rules.Omitted)
+		}
+		if rules.Withheld > 0 {
+			fmt.Printf("  %d sensitive note(s) left out\n", rules.Withheld)
+		}
+	}})
+}
+
+// writeFileAtomic writes data next to name and renames it into place, so a
+// failed write doesn't leave a truncated file behind.
+func writeFileAtomic(name string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".braindump-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// formatter renders a command's output to w.
+type formatter func(w io.Writer, out output) error
+
+// formatters is the registry of --format values, in the order shown in help.
+var formatters = []struct {
+	name   string
+	render formatter
+}{
+	{"text", formatText},
+	{"json", formatJSON},
+	{"ndjson", formatNDJSON},
+	{"yaml", formatYAML},
+	{"markdown", formatMarkdown},
+	{"csv", formatCSV},
+	{"table", formatTable},
+	{"hook", formatHook},
+}
+
+func lookupFormatter(name string) (formatter, error) {
+	for _, f := range formatters {
+		if f.name == name {
+			return f.render, nil
+		}
+	}
+	return nil, &usageError{fmt.Errorf("unknown format %q (use %s)", name, strings.Join(formatNames(), ", "))}
+}
+
+func formatNames() []string {
+	names := make([]string, len(formatters))
+	for i, f := range formatters {
+		names[i] = f.name
+	}
+	return names
+}
+
+// tabular is implemented by payloads that can be shown as rows by the csv,
+// table and markdown formats.
+type tabular interface {
+	header() []string
+	rows() [][]string
+}
+
+func asTabular(data interface{}) (tabular, error) {
+	switch v := data.(type) {
+	case *models.Note:
+		return noteList{v}, nil
+	case tabular:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("output cannot be shown as a table")
+	}
+}
+
+// items splits a payload into the values written one per line by ndjson.
+func items(data interface{}) []interface{} {
+	var result []interface{}
+	switch v := data.(type) {
+	case noteList:
+		for _, n := range v {
+			result = append(result, n)
+		}
+	case categoryCounts:
+		for _, c := range v {
+			result = append(result, c)
+		}
+	case tagCounts:
+		for _, t := range v {
+			result = append(result, t)
+		}
+	case configEntries:
+		for _, e := range v {
+			result = append(result, e)
+		}
+	case findingList:
+		for _, f := range v {
+			result = append(result, f)
+		}
+	case auditEntries:
+		for _, e := range v {
+			result = append(result, e)
+		}
+	default:
+		result = append(result, data)
+	}
+	return result
+}
+
+func formatText(w io.Writer, out output) error {
+	out.text()
+	return nil
+}
+
+func formatJSON(w io.Writer, out output) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(envelope{Version: jsonVersion, Kind: out.kind, Data: out.data})
+}
+
+// formatNDJSON writes one compact JSON value per line, without the
+// envelope, so results can be streamed through jq or line-based tools.
+func formatNDJSON(w io.Writer, out output) error {
+	encoder := json.NewEncoder(w)
+	for _, item := range items(out.data) {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatYAML(w io.Writer, out output) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(envelope{Version: jsonVersion, Kind: out.kind, Data: out.data}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// formatMarkdown renders notes as consecutive markdown documents, ready to
+// paste into a prompt. Other payloads become a markdown table.
+func formatMarkdown(w io.Writer, out output) error {
+	var list noteList
+	switch v := out.data.(type) {
+	case *models.Note:
+		list = noteList{v}
+	case noteList:
+		list = v
+	default:
+		t, err := asTabular(out.data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(t.header(), " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(t.header())))
+		for _, row := range t.rows() {
+			for i, cell := range row {
+				row[i] = strings.ReplaceAll(strings.ReplaceAll(cell, "|", `\|`), "\n", " ")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		}
+		return nil
+	}
+
+	for i, note := range list {
+		if i > 0 {
+			fmt.Fprint(w, "\n---\n\n")
+		}
+		fmt.Fprint(w, braindump.Markdown(note))
+	}
+	return nil
+}
+
+func formatCSV(w io.Writer, out output) error {
+	t, err := asTabular(out.data)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write(t.header())
+	writer.WriteAll(t.rows())
+	return writer.Error()
+}
+
+// tableCellWidth caps the width of table cells so long content doesn't
+// break the alignment.
+const tableCellWidth = 40
+
+func formatTable(w io.Writer, out output) error {
+	t, err := asTabular(out.data)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(t.header(), "\t")))
+	for _, row := range t.rows() {
+		for i, cell := range row {
+			cell = strings.Join(strings.Fields(cell), " ")
+			if r := []rune(cell); len(r) > tableCellWidth {
+				cell = string(r[:tableCellWidth-3]) + "..."
+			}
+			row[i] = cell
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// hookPayload is implemented by output meant to be injected into an agent
+// session by a harness hook.
+type hookPayload interface {
+	hookContext() string
+}
+
+// formatHook writes the response a session-start hook returns to its
+// harness, carrying the payload as additional context.
+func formatHook(w io.Writer, out output) error {
+	payload, ok := out.data.(hookPayload)
+	if !ok {
+		return &usageError{fmt.Errorf("the hook format is only supported by the context command")}
+	}
+
+	type hookOutput struct {
+		HookEventName     string `json:"hookEventName"`
+		AdditionalContext string `json:"additionalContext"`
+	}
+	return json.NewEncoder(w).Encode(map[string]hookOutput{
+		"hookSpecificOutput": {HookEventName: "SessionStart", AdditionalContext: payload.hookContext()},
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/MohGanji/braindump/pkg/braindump"
 	"github.com/MohGanji/braindump/pkg/models"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +20,8 @@ var getCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(getCmd)
+	addRevealFlag(getCmd)
+	addGuardFlag(getCmd)
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
@@ -28,48 +31,38 @@ func runGet(cmd *cobra.Command, args []string) error {
 		titlePattern = args[1]
 	}
 
-	notes, err := store.List(category)
+	notes, err := client.List(cmd.Context(), braindump.ListFilter{Category: category, Title: titlePattern})
 	if err != nil {
 		return fmt.Errorf("failed to get notes: %w", err)
 	}
-
-	if titlePattern != "" {
-		notes = filterByTitle(notes, titlePattern)
+	if err := protect(cmd, notes...); err != nil {
+		return err
 	}
-
-	if len(notes) == 0 {
-		fmt.Println("No notes found")
-		return nil
+	if err := guard(cmd, notes); err != nil {
+		return err
 	}
 
-	if formatFlag == "json" {
-		return outputJSON(notes)
-	}
-
-	for i, note := range notes {
-		if i > 0 {
-			fmt.Println()
+	return render(output{kind: kindNotes, data: asNoteList(notes), text: func() {
+		if len(notes) == 0 {
+			fmt.Println("No notes found")
+			return
 		}
-		printNote(note)
-	}
 
-	return nil
-}
-
-func filterByTitle(notes []*models.Note, pattern string) []*models.Note {
-	patternLower := strings.ToLower(pattern)
-	var filtered []*models.Note
-	for _, note := range notes {
-		if strings.Contains(strings.ToLower(note.Title), patternLower) {
-			filtered = append(filtered, note)
+		for i, note := range notes {
+			if i > 0 {
+				fmt.Println()
+			}
+			printNote(note)
 		}
-	}
-	return filtered
+	}})
 }
 
 func printNote(note *models.Note) {
-	fmt.Printf("%s (%s)\n", note.Title, note.ID[:8])
+	fmt.Printf("%s (%s)\n", note.Title, idLabel(note))
 	fmt.Println(strings.Repeat("-", len(note.Title)+11))
+	if warning := injectionWarning(note); warning != "" {
+		fmt.Println(warning)
+	}
 	fmt.Println(note.Content)
 	fmt.Println()
 	fmt.Printf("Created: %s\n", note.Created.Format("2006-01-02 15:04:05"))
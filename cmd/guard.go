@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var guardFlag string
+
+// addGuardFlag registers --guard on a command that prints note content.
+func addGuardFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&guardFlag, "guard", "", "guard against prompt injection in content (off|flag|wrap, default from config)")
+}
+
+// guardMode returns the prompt-injection guard chosen with --guard, or
+// else in the config.
+func guardMode(cmd *cobra.Command) (injection.Mode, error) {
+	name := settings.Injection
+	if cmd.Flags().Changed("guard") {
+		name = guardFlag
+	}
+	mode, err := injection.ParseMode(name)
+	if err != nil {
+		return "", &usageError{err}
+	}
+	return mode, nil
+}
+
+// guard applies the prompt-injection guard to notes before they are
+// printed.
+func guard(cmd *cobra.Command, notes []*models.Note) error {
+	mode, err := guardMode(cmd)
+	if err != nil {
+		return err
+	}
+	braindump.GuardInjection(notes, mode)
+	return nil
+}
+
+// injectionWarning tells the reader of text output that a note looks like
+// it carries instructions. It is empty for other notes.
+func injectionWarning(note *models.Note) string {
+	if len(note.Injection) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Warning: possible prompt injection (%s)", strings.Join(note.Injection, ", "))
+}
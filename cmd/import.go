@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MohGanji/braindump/pkg/archive"
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importOnConflict string
+	importDryRun     bool
+)
+
+var importObsidianCmd = &cobra.Command{
+	Use:   "obsidian <vault>",
+	Short: "Import notes from an Obsidian vault",
+	Long: `Import the notes of an Obsidian vault. Folders become categories, and
+notes at the top of the vault go to a category named after it. Frontmatter
+tags and #tags in the text become tags. [[Wikilinks]] are kept as written,
+and the IDs of the notes they point to are listed in the "links" metadata.
+Created and updated times come from the frontmatter, or else from the file.
+
+Each note's ID is derived from its path in the vault, so importing the vault
+again finds the notes imported before; --on-conflict newer brings them up
+to date.`,
+	Example: `  braindump import obsidian ~/vaults/team --dry-run
+  braindump import obsidian ~/vaults/team --on-conflict newer`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importFrom(cmd, args[0], archive.ReadVault)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|dir>",
+	Short: "Import notes from JSONL, an archive or a directory",
+	Long: `Import notes from a JSONL file (one note per line), an archive written by
+"braindump export", or a directory of markdown files. Files without the
+store's frontmatter take their title from the file name, their category
+from their folder and their times from the file.
+
+An imported note conflicts with a note that has the same ID, or the same
+category and title. --on-conflict decides what happens:
+
+  skip       keep the existing note (default)
+  overwrite  replace it
+  rename     import under a new ID, and a new title if the title is taken
+  newer      keep whichever was updated last
+
+Archives are checked against their manifest's checksums first. All notes are
+checked before any is written and are written in one batch, so a failed
+import changes nothing.`,
+	Example: `  braindump import backup.tar.gz
+  braindump import notes.jsonl --on-conflict newer
+  braindump import ~/old-notes --dry-run
+  braindump import obsidian ~/vaults/team`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importObsidianCmd)
+	importCmd.PersistentFlags().StringVar(&importOnConflict, "on-conflict", "skip", "what to do with conflicting notes (skip|overwrite|rename|newer)")
+	importCmd.PersistentFlags().BoolVar(&importDryRun, "dry-run", false, "show what would be imported without changing the store")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	return importFrom(cmd, args[0], archive.Read)
+}
+
+// importFrom imports the notes read from source by read.
+func importFrom(cmd *cobra.Command, source string, read func(string) (*archive.Contents, error)) error {
+	policy, err := braindump.ParseConflictPolicy(importOnConflict)
+	if err != nil {
+		return &usageError{err}
+	}
+
+	contents, err := read(source)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	report, err := client.Import(cmd.Context(), contents.Notes, braindump.ImportOptions{OnConflict: policy, DryRun: importDryRun})
+	if err != nil {
+		return fmt.Errorf("failed to import notes: %w", err)
+	}
+
+	return render(output{kind: kindImport, data: report, text: func() { printImportReport(report) }})
+}
+
+func printImportReport(report *braindump.ImportReport) {
+	for _, c := range report.Conflicts {
+		line := fmt.Sprintf("  %-9s [%s] %s (%s)", c.Action, c.Category, c.Title, c.ID[:8])
+		if c.Action == braindump.ImportRename {
+			line += fmt.Sprintf(" -> %s (%s)", c.NewTitle, c.NewID[:8])
+		}
+		fmt.Printf("%s, conflicts with %s\n", line, c.With[:8])
+	}
+	if len(report.Conflicts) > 0 {
+		fmt.Println()
+	}
+
+	summary := fmt.Sprintf("%d added, %d overwritten, %d renamed, %d skipped", report.Added, report.Overwritten, report.Renamed, report.Skipped)
+	if report.DryRun {
+		fmt.Printf("Dry run, nothing imported: %d note(s) read, would be %s\n", report.Read, summary)
+		return
+	}
+	fmt.Printf("✓ Imported %d of %d note(s): %s\n", report.Added+report.Overwritten+report.Renamed, report.Read, summary)
+}
@@ -5,20 +5,28 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
 	"github.com/spf13/cobra"
 )
 
+var listNoRecurse bool
+
 var listCmd = &cobra.Command{
 	Use:   "list [category]",
 	Short: "List notes",
 	Example: `  braindump list
-  braindump list api-creds`,
+  braindump list api-creds
+  braindump list clients --no-recurse`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runList,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVar(&listNoRecurse, "no-recurse", false, "exclude notes in subcategories")
+	addRevealFlag(listCmd)
+	addGuardFlag(listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -27,18 +35,24 @@ func runList(cmd *cobra.Command, args []string) error {
 		category = args[0]
 	}
 
-	notes, err := store.List(category)
+	notes, err := client.List(cmd.Context(), braindump.ListFilter{Category: category, NoRecurse: listNoRecurse})
 	if err != nil {
 		return fmt.Errorf("failed to list notes: %w", err)
 	}
+	if err := protect(cmd, notes...); err != nil {
+		return err
+	}
+	if err := guard(cmd, notes); err != nil {
+		return err
+	}
+
+	return render(output{kind: kindNotes, data: asNoteList(notes), text: func() { printList(notes) }})
+}
 
+func printList(notes []*models.Note) {
 	if len(notes) == 0 {
 		fmt.Println("No notes found")
-		return nil
-	}
-
-	if formatFlag == "json" {
-		return outputJSON(notes)
+		return
 	}
 
 	sort.Slice(notes, func(i, j int) bool {
@@ -65,9 +79,15 @@ func runList(cmd *cobra.Command, args []string) error {
 		preview = strings.ReplaceAll(preview, "\n", " ")
 
 		fmt.Printf("  %s - %s\n", note.Title, preview)
-		fmt.Printf("    ID: %s | Created: %s\n", note.ID[:8], note.Created.Format("2006-01-02 15:04"))
+		fmt.Printf("    ID: %s | Created: %s", note.ID[:8], note.Created.Format("2006-01-02 15:04"))
+		if note.Scope != "" {
+			fmt.Printf(" | Scope: %s", note.Scope)
+		}
+		fmt.Println()
+		if warning := injectionWarning(note); warning != "" {
+			fmt.Printf("    %s\n", warning)
+		}
 	}
 
 	fmt.Printf("\nTotal: %d note(s)\n", len(notes))
-	return nil
 }
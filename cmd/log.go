@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logID     string
+	logActor  string
+	logAction string
+	logSince  string
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the audit log of changes and reveals",
+	Long: `Show who added, updated, moved, deleted and revealed notes, oldest first.
+
+Entries carry SHA-256 hashes of the stored content before and after each
+change. --since takes a duration (30m, 12h, 1d, 2w) or a date (2006-01-02 or
+RFC 3339).`,
+	Example: `  braindump log --since 1d
+  braindump log --id a1b2c3d4
+  braindump log --actor claude --action delete --format json`,
+	Args: cobra.NoArgs,
+	RunE: runLog,
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().StringVar(&logID, "id", "", "only entries for this note ID or ID prefix")
+	logCmd.Flags().StringVar(&logActor, "actor", "", "only entries by this actor")
+	logCmd.Flags().StringVar(&logAction, "action", "", "only entries of this action (add, update, delete, move, reveal)")
+	logCmd.Flags().StringVar(&logSince, "since", "", "only entries since a duration ago or a date")
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	filter := audit.Filter{ID: logID, Actor: logActor, Action: logAction}
+	if logSince != "" {
+		since, err := parseSince(logSince, time.Now())
+		if err != nil {
+			return &usageError{err}
+		}
+		filter.Since = since
+	}
+
+	entries, err := client.AuditLog(cmd.Context(), filter)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return render(output{kind: kindLog, data: auditEntries(entries), text: func() {
+		if len(entries) == 0 {
+			fmt.Println("No entries found")
+			return
+		}
+		for _, e := range entries {
+			id := e.ID
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			line := fmt.Sprintf("%s  %-6s  %s  [%s] %s", e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, id, e.Category, e.Title)
+			if e.Actor != "" {
+				line += "  by " + e.Actor
+			}
+			if e.Before != "" || e.After != "" {
+				line += fmt.Sprintf("  (%s -> %s)", shortHash(e.Before), shortHash(e.After))
+			}
+			fmt.Println(line)
+		}
+	}})
+}
+
+// parseSince reads a duration before now, with d and w for days and weeks,
+// or a date.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if n, err := strconv.Atoi(strings.TrimRight(s, "dw")); err == nil && n >= 0 {
+		switch {
+		case strings.HasSuffix(s, "d"):
+			return now.AddDate(0, 0, -n), nil
+		case strings.HasSuffix(s, "w"):
+			return now.AddDate(0, 0, -7*n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a duration such as 1d or a date such as 2006-01-02)", s)
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "none"
+	}
+	return hash[:8]
+}
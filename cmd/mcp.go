@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/MohGanji/braindump/pkg/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve the store over the Model Context Protocol (stdio)",
+	Long: `Run an MCP server on stdin/stdout.
+
+Exposes add, search, get, list, update, append and delete as tools, and every
+note as a resource (braindump://notes/<id>). Register it with an MCP client,
+for example:
+
+  {"mcpServers": {"braindump": {"command": "braindump", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	server := mcp.NewServer(client, Version)
+	return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+}
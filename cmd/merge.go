@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var mergeInto string
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <id>... --into <id>",
+	Short: "Merge notes into one",
+	Long: `Merge the content and tags of one or more notes into a target note.
+
+Each merged note is appended to the target under a "## <title>" heading and
+then deleted. The IDs of the merged notes are recorded in the target's
+metadata (merged_from).`,
+	Example: `  braindump merge a1b2c3d4 e5f6a7b8 --into 9c8d7e6f`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().StringVar(&mergeInto, "into", "", "ID or title of the note to merge into")
+	mergeCmd.MarkFlagRequired("into")
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	target, merged, err := client.Merge(cmd.Context(), mergeInto, args)
+	if err != nil {
+		return fmt.Errorf("failed to merge notes: %w", err)
+	}
+	if err := protect(cmd, target); err != nil {
+		return err
+	}
+
+	return render(output{kind: kindNote, data: target, text: func() {
+		fmt.Printf("✓ Merged %d note(s) into \"%s\" (id: %s)\n", merged, target.Title, idLabel(target))
+	}})
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/archive"
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// jsonVersion is bumped whenever the shape of --format json output changes
+// incompatibly.
+const jsonVersion = 1
+
+// Kinds of structured output.
+const (
+	kindNote           = "note"
+	kindNotes          = "notes"
+	kindDeleted        = "deleted"
+	kindCategories     = "categories"
+	kindTags           = "tags"
+	kindCategoryChange = "category_change"
+	kindTagChange      = "tag_change"
+	kindRecall         = "recall"
+	kindPrimer         = "primer"
+	kindConfig         = "config"
+	kindFindings       = "findings"
+	kindLog            = "log"
+	kindExport         = "export"
+	kindImport         = "import"
+	kindRules          = "rules"
+)
+
+// output is the result of a command, rendered by the formatter selected with
+// --format.
+type output struct {
+	kind string
+	data interface{}
+	// text prints the human-readable form to stdout
+	text func()
+}
+
+// render writes out to stdout in the selected format.
+func render(out output) error {
+	f, err := lookupFormatter(formatFlag)
+	if err != nil {
+		return err
+	}
+	return f(os.Stdout, out)
+}
+
+// idLabel is the short ID shown in text output, followed by the note's
+// scope if a project store is in use.
+func idLabel(note *models.Note) string {
+	if note.Scope == "" {
+		return note.ID[:8]
+	}
+	return note.ID[:8] + ", " + note.Scope
+}
+
+// envelope wraps json and yaml output so consumers can check the schema
+// version and payload kind before decoding data.
+type envelope struct {
+	Version int         `json:"version" yaml:"version"`
+	Kind    string      `json:"kind" yaml:"kind"`
+	Data    interface{} `json:"data" yaml:"data"`
+}
+
+// noteList is a list of notes that encodes as [] rather than null when
+// empty.
+type noteList []*models.Note
+
+func asNoteList(list []*models.Note) noteList {
+	if list == nil {
+		return noteList{}
+	}
+	return noteList(list)
+}
+
+func (l noteList) header() []string {
+	return []string{"id", "category", "scope", "title", "tags", "created", "updated", "content"}
+}
+
+func (l noteList) rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, n := range l {
+		rows[i] = []string{
+			n.ID,
+			n.Category,
+			n.Scope,
+			n.Title,
+			strings.Join(n.Tags, ","),
+			n.Created.Format(time.RFC3339),
+			n.Updated.Format(time.RFC3339),
+			n.Content,
+		}
+	}
+	return rows
+}
+
+type deletedNotes struct {
+	IDs []string `json:"ids" yaml:"ids"`
+}
+
+func (d deletedNotes) header() []string { return []string{"id"} }
+
+func (d deletedNotes) rows() [][]string {
+	rows := make([][]string, len(d.IDs))
+	for i, id := range d.IDs {
+		rows[i] = []string{id}
+	}
+	return rows
+}
+
+type categoryCount struct {
+	Category string `json:"category" yaml:"category"`
+	Count    int    `json:"count" yaml:"count"`
+}
+
+type categoryCounts []categoryCount
+
+func (c categoryCounts) header() []string { return []string{"category", "count"} }
+
+func (c categoryCounts) rows() [][]string {
+	rows := make([][]string, len(c))
+	for i, cc := range c {
+		rows[i] = []string{cc.Category, strconv.Itoa(cc.Count)}
+	}
+	return rows
+}
+
+type tagCount struct {
+	Tag   string `json:"tag" yaml:"tag"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type tagCounts []tagCount
+
+func (t tagCounts) header() []string { return []string{"tag", "count"} }
+
+func (t tagCounts) rows() [][]string {
+	rows := make([][]string, len(t))
+	for i, tc := range t {
+		rows[i] = []string{tc.Tag, strconv.Itoa(tc.Count)}
+	}
+	return rows
+}
+
+type categoryChange struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+func (c categoryChange) header() []string { return []string{"from", "to"} }
+func (c categoryChange) rows() [][]string { return [][]string{{c.From, c.To}} }
+
+type tagChange struct {
+	From  []string `json:"from,omitempty" yaml:"from,omitempty"`
+	To    string   `json:"to,omitempty" yaml:"to,omitempty"`
+	Notes int      `json:"notes" yaml:"notes"`
+}
+
+func (t tagChange) header() []string { return []string{"from", "to", "notes"} }
+
+func (t tagChange) rows() [][]string {
+	return [][]string{{strings.Join(t.From, ","), t.To, strconv.Itoa(t.Notes)}}
+}
+
+// recallOutput shows the notes packed by recall as rows.
+type recallOutput struct {
+	*braindump.RecallResult `yaml:",inline"`
+}
+
+func (r recallOutput) header() []string {
+	return []string{"id", "category", "title", "score", "snippet", "tokens"}
+}
+
+func (r recallOutput) rows() [][]string {
+	rows := make([][]string, len(r.Notes))
+	for i, n := range r.Notes {
+		rows[i] = []string{n.ID, n.Category, n.Title, strconv.Itoa(n.Score), strconv.FormatBool(n.Snippet), strconv.Itoa(n.Tokens)}
+	}
+	return rows
+}
+
+// primerOutput shows the notes of a session primer as rows.
+type primerOutput struct {
+	*braindump.PrimerResult `yaml:",inline"`
+}
+
+func (p primerOutput) header() []string {
+	return []string{"id", "category", "title", "section", "full"}
+}
+
+func (p primerOutput) rows() [][]string {
+	rows := make([][]string, len(p.Notes))
+	for i, n := range p.Notes {
+		rows[i] = []string{n.ID, n.Category, n.Title, n.Section, strconv.FormatBool(n.Full)}
+	}
+	return rows
+}
+
+func (p primerOutput) hookContext() string { return p.Text }
+
+type configEntry struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type configEntries []configEntry
+
+func (c configEntries) header() []string { return []string{"key", "value"} }
+
+func (c configEntries) rows() [][]string {
+	rows := make([][]string, len(c))
+	for i, e := range c {
+		rows[i] = []string{e.Key, e.Value}
+	}
+	return rows
+}
+
+type findingList []braindump.NoteFindings
+
+func (f findingList) header() []string {
+	return []string{"id", "category", "title", "rule", "line", "preview"}
+}
+
+func (f findingList) rows() [][]string {
+	var rows [][]string
+	for _, nf := range f {
+		for _, finding := range nf.Findings {
+			rows = append(rows, []string{nf.ID, nf.Category, nf.Title, finding.Rule, strconv.Itoa(finding.Line), finding.Preview})
+		}
+	}
+	return rows
+}
+
+// exportResult says where an export was written and what it holds.
+type exportResult struct {
+	Path     string            `json:"path" yaml:"path"`
+	Format   archive.Format    `json:"format" yaml:"format"`
+	Manifest *archive.Manifest `json:"manifest" yaml:"manifest"`
+}
+
+// rulesResult says which rules file section was written and what it holds.
+type rulesResult struct {
+	Path string `json:"path" yaml:"path"`
+	// Changed is false if the section was already up to date
+	Changed                bool `json:"changed" yaml:"changed"`
+	*braindump.RulesResult `yaml:",inline"`
+}
+
+func (r rulesResult) header() []string {
+	return []string{"id", "category", "title", "full"}
+}
+
+func (r rulesResult) rows() [][]string {
+	rows := make([][]string, len(r.Notes))
+	for i, n := range r.Notes {
+		rows[i] = []string{n.ID, n.Category, n.Title, strconv.FormatBool(n.Full)}
+	}
+	return rows
+}
+
+type auditEntries []audit.Entry
+
+func (a auditEntries) header() []string {
+	return []string{"time", "action", "actor", "id", "category", "title", "before", "after", "command"}
+}
+
+func (a auditEntries) rows() [][]string {
+	rows := make([][]string, len(a))
+	for i, e := range a {
+		rows[i] = []string{e.Time.Format(time.RFC3339), e.Action, e.Actor, e.ID, e.Category, e.Title, e.Before, e.After, e.Command}
+	}
+	return rows
+}
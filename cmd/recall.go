@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recallBudget   int
+	recallStyle    string
+	recallCategory string
+	recallTags     string
+)
+
+var recallCmd = &cobra.Command{
+	Use:   "recall <task>",
+	Short: "Pack the notes relevant to a task into a prompt-ready block",
+	Long: `Search for notes matching the keywords of a task description and pack the
+most relevant ones into a single block that fits a token budget.
+
+Notes are included whole while they fit; a note that doesn't is cut down to
+the lines mentioning a keyword. Tokens are estimated, not counted by a real
+tokenizer, and the estimate errs on the high side. The block is written to
+stdout and a summary of the included note IDs to stderr.`,
+	Example: `  braindump recall "add stripe webhooks to the billing service"
+  braindump recall "deploy to staging" --budget 500 --style markdown`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRecall,
+}
+
+func init() {
+	rootCmd.AddCommand(recallCmd)
+	recallCmd.Flags().IntVar(&recallBudget, "budget", 2000, "maximum estimated tokens")
+	recallCmd.Flags().StringVar(&recallStyle, "style", braindump.RecallXML, "block style (xml|markdown)")
+	recallCmd.Flags().StringVar(&recallCategory, "in", "", "recall only from this category")
+	recallCmd.Flags().StringVar(&recallTags, "tag", "", "filter by tags (comma-separated)")
+	addRevealFlag(recallCmd)
+	addGuardFlag(recallCmd)
+}
+
+func runRecall(cmd *cobra.Command, args []string) error {
+	mode, err := guardMode(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	if recallTags != "" {
+		tags = strings.Split(recallTags, ",")
+		for i, tag := range tags {
+			tags[i] = strings.TrimSpace(tag)
+		}
+	}
+
+	result, err := client.Recall(cmd.Context(), args[0], braindump.RecallOptions{
+		Budget:   recallBudget,
+		Style:    recallStyle,
+		Category: recallCategory,
+		Tags:     tags,
+		Reveal:   revealFlag,
+		Guard:    mode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recall: %w", err)
+	}
+
+	return render(output{kind: kindRecall, data: recallOutput{result}, text: func() {
+		fmt.Print(result.Block)
+
+		snippets := 0
+		short := make([]string, len(result.Notes))
+		for i, n := range result.Notes {
+			short[i] = n.ID[:8]
+			if n.Snippet {
+				snippets++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Included %d note(s), %d as snippets, ~%d/%d tokens: %s\n",
+			len(result.Notes), snippets, result.Tokens, result.Budget, strings.Join(short, ", "))
+	}})
+}
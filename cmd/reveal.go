@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var revealFlag bool
+
+// addRevealFlag registers --reveal on a command that prints note content.
+func addRevealFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&revealFlag, "reveal", false, "show the content of sensitive notes (recorded in the audit log)")
+}
+
+// protect hides the content of sensitive notes before they are printed,
+// unless --reveal is set (see braindump.Client.Protect). Commands without
+// --reveal always hide it.
+func protect(cmd *cobra.Command, notes ...*models.Note) error {
+	return client.Protect(notes, revealFlag, cmd.CommandPath())
+}
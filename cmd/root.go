@@ -3,16 +3,26 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
-	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/config"
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/secrets"
 	"github.com/spf13/cobra"
 )
 
 var (
-	store      storage.Store
-	storePath  string
-	formatFlag string
+	client      *braindump.Client
+	storePath   string
+	formatFlag  string
+	globalFlag  bool
+	profileFlag string
+	actorFlag   string
+	readOnly    bool
+
+	// settings are the resolved config file, profile and environment settings
+	settings config.Settings
 
 	// Version is set at build time via ldflags
 	Version = "dev"
@@ -27,31 +37,135 @@ var rootCmd = &cobra.Command{
   braindump search "stripe"
   braindump list api-creds
   braindump get api-creds "stripe"`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// config commands must work even when the config doesn't resolve
+		if isConfigCommand(cmd) {
+			_, err := lookupFormatter(formatFlag)
+			return err
+		}
+
+		if err := loadSettings(cmd); err != nil {
+			return err
+		}
+		if _, err := lookupFormatter(formatFlag); err != nil {
+			return err
+		}
+		// Reject this before a mutation runs rather than when printing it
+		if formatFlag == "hook" && cmd != contextCmd {
+			return &usageError{fmt.Errorf("the hook format is only supported by the context command")}
+		}
+		return initStore(cmd)
+	},
 }
 
 func Execute() error {
+	markUsageErrors(rootCmd)
 	return rootCmd.Execute()
 }
 
 func init() {
-	cobra.OnInitialize(initStore)
-	rootCmd.PersistentFlags().StringVar(&storePath, "store", getDefaultStorePath(), "path to notes directory")
-	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "text", "output format (text|json)")
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.PersistentFlags().StringVar(&storePath, "store", braindump.DefaultPath(), "path to notes directory")
+	rootCmd.PersistentFlags().BoolVar(&globalFlag, "global", false, "add new notes to the global store, not the project store (.braindump/ in this or a parent directory)")
+	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "text", "output format ("+strings.Join(formatNames(), "|")+")")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "fail every command that would change the store (default: $BRAINDUMP_READ_ONLY)")
+	rootCmd.PersistentFlags().StringVar(&actorFlag, "actor", "", "who changes are attributed to in the audit log (default: $BRAINDUMP_ACTOR)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "config profile to use (default: $BRAINDUMP_PROFILE or the config's profile)")
 }
 
-func initStore() {
-	var err error
-	store, err = storage.NewFileStore(storePath)
+// loadSettings resolves the settings of the selected profile, overridden by
+// BRAINDUMP_* environment variables, and applies them to flags that weren't
+// given.
+func loadSettings(cmd *cobra.Command) error {
+	cfg, err := config.Load(config.Path())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to initialize store: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+
+	profile := profileFlag
+	if profile == "" {
+		profile = os.Getenv("BRAINDUMP_PROFILE")
+	}
+	resolved, err := cfg.Resolve(profile)
+	if err != nil {
+		return &usageError{err}
+	}
+	settings = config.Merge(resolved, config.Env())
+
+	if actorFlag == "" {
+		actorFlag = os.Getenv("BRAINDUMP_ACTOR")
+	}
+
+	flags := cmd.Flags()
+	if !flags.Changed("store") && settings.Store != "" {
+		storePath = settings.Store
+	}
+	if !flags.Changed("format") && settings.Format != "" {
+		formatFlag = settings.Format
 	}
+	return nil
 }
 
-func getDefaultStorePath() string {
-	home, err := os.UserHomeDir()
+func initStore(cmd *cobra.Command) error {
+	opts := []braindump.Option{
+		braindump.WithPath(storePath),
+		braindump.WithActor(actorFlag),
+		braindump.WithSensitiveTags(settings.SensitiveTags),
+	}
+	if readOnly || settings.ReadOnly {
+		opts = append(opts, braindump.WithReadOnly())
+	}
+	if settings.Tags != nil {
+		opts = append(opts, braindump.WithTagDefaults(*settings.Tags))
+	}
+
+	passphrase, err := settings.Passphrase()
+	if err != nil {
+		return err
+	}
+	if passphrase != "" {
+		opts = append(opts, braindump.WithPassphrase(passphrase))
+	}
+
+	policy, err := secrets.ParsePolicy(settings.Secrets)
+	if err != nil {
+		return &usageError{err}
+	}
+	opts = append(opts, braindump.WithSecretPolicy(policy, reportSecrets(policy)))
+
+	// A project store found from the working directory, or the directory
+	// context is asked about, is layered over the store unless --store names
+	// the one to use; --global only sends new notes to the store below it
+	dir := "."
+	if cmd == contextCmd {
+		dir = contextCwd
+	}
+	if project, ok := braindump.FindProjectStore(dir); ok && !cmd.Flags().Changed("store") {
+		opts = append(opts, braindump.WithProjectPath(project))
+		if globalFlag {
+			opts = append(opts, braindump.WithGlobalAdds())
+		}
+	}
+
+	client, err = braindump.New(opts...)
 	if err != nil {
-		return ".braindump"
+		return fmt.Errorf("failed to initialize store: %w", err)
+	}
+	return nil
+}
+
+// reportSecrets returns a callback telling the user on stderr what policy
+// did with the secrets found in a note.
+func reportSecrets(policy secrets.Policy) func(note *models.Note, findings []secrets.Finding) {
+	return func(note *models.Note, findings []secrets.Finding) {
+		switch policy {
+		case secrets.PolicyRedact:
+			fmt.Fprintf(os.Stderr, "Redacted secrets from %q: %s\n", note.Title, secrets.Summary(findings))
+		case secrets.PolicyEncrypt:
+			fmt.Fprintf(os.Stderr, "Encrypting %q, it contains secrets: %s\n", note.Title, secrets.Summary(findings))
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: %q contains secrets: %s (use --encrypt to store them encrypted)\n", note.Title, secrets.Summary(findings))
+		}
 	}
-	return filepath.Join(home, ".braindump")
 }
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var scanInjection bool
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [category]",
+	Short: "Find secrets or prompt-injection patterns in notes",
+	Long: `Scan notes for AWS keys, Stripe keys, GitHub tokens, private keys,
+credential assignments and high-entropy strings. Encrypted notes are skipped.
+
+Secrets are shown masked. Encrypt a note found with
+"braindump update <id> --encrypt".
+
+With --injection, scan notes for prompt-injection patterns instead:
+instructions to ignore earlier ones, role overrides, chat markup, tool-call
+syntax and hidden Unicode characters.`,
+	Example: `  braindump scan
+  braindump scan api-creds --format json
+  braindump scan --injection`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScan,
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.Flags().BoolVar(&scanInjection, "injection", false, "find prompt-injection patterns instead of secrets")
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	var category string
+	if len(args) > 0 {
+		category = args[0]
+	}
+
+	scan, found := client.ScanSecrets, "secrets"
+	if scanInjection {
+		scan, found = client.ScanInjection, "injection patterns"
+	}
+	reports, err := scan(cmd.Context(), category)
+	if err != nil {
+		return fmt.Errorf("failed to scan notes: %w", err)
+	}
+
+	return render(output{kind: kindFindings, data: findingList(reports), text: func() {
+		if len(reports) == 0 {
+			fmt.Printf("No %s found\n", found)
+			return
+		}
+		for _, r := range reports {
+			label := r.ID[:8]
+			if r.Scope != "" {
+				label += ", " + r.Scope
+			}
+			fmt.Printf("[%s] %s (%s)\n", r.Category, r.Title, label)
+			for _, f := range r.Findings {
+				if f.Line == 0 {
+					fmt.Printf("  title: %s %s\n", f.Rule, f.Preview)
+					continue
+				}
+				fmt.Printf("  line %d: %s %s\n", f.Line, f.Rule, f.Preview)
+			}
+		}
+		fmt.Printf("\nFound %s in %d note(s)\n", found, len(reports))
+	}})
+}
@@ -2,10 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 
-	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/braindump"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +27,8 @@ func init() {
 	rootCmd.AddCommand(searchCmd)
 	searchCmd.Flags().StringVar(&searchCategory, "in", "", "search only in this category")
 	searchCmd.Flags().StringVar(&searchTags, "tag", "", "filter by tags (comma-separated)")
+	addRevealFlag(searchCmd)
+	addGuardFlag(searchCmd)
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -41,34 +42,36 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	results, err := store.Search(query, searchCategory, tags)
+	results, err := client.Search(cmd.Context(), query, braindump.SearchFilter{Category: searchCategory, Tags: tags})
 	if err != nil {
 		return fmt.Errorf("failed to search: %w", err)
 	}
+	if err := protect(cmd, braindump.Notes(results)...); err != nil {
+		return err
+	}
+	if err := guard(cmd, braindump.Notes(results)); err != nil {
+		return err
+	}
+
+	return render(output{kind: kindNotes, data: asNoteList(braindump.Notes(results)), text: func() {
+		printSearchResults(results, query)
+	}})
+}
 
+func printSearchResults(results []braindump.SearchResult, query string) {
 	if len(results) == 0 {
 		fmt.Println("No notes found")
-		return nil
+		return
 	}
 
-	scoredResults := rankResults(results, query)
-	sort.Slice(scoredResults, func(i, j int) bool {
-		return scoredResults[i].score > scoredResults[j].score
-	})
+	fmt.Printf("Found %d note(s):\n\n", len(results))
 
-	if formatFlag == "json" {
-		notes := make([]*models.Note, len(scoredResults))
-		for i, r := range scoredResults {
-			notes[i] = r.note
+	for _, result := range results {
+		note := result.Note
+		fmt.Printf("  [%s] %s (%s)\n", note.Category, note.Title, idLabel(note))
+		if warning := injectionWarning(note); warning != "" {
+			fmt.Printf("  %s\n", warning)
 		}
-		return outputJSON(notes)
-	}
-
-	fmt.Printf("Found %d note(s):\n\n", len(scoredResults))
-
-	for _, result := range scoredResults {
-		note := result.note
-		fmt.Printf("  [%s] %s (%s)\n", note.Category, note.Title, note.ID[:8])
 
 		preview := getMatchPreview(note.Content, query)
 		if preview != "" {
@@ -76,42 +79,6 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Println()
 	}
-
-	return nil
-}
-
-type scoredResult struct {
-	note  *models.Note
-	score int
-}
-
-func rankResults(notes []*models.Note, query string) []scoredResult {
-	queryLower := strings.ToLower(query)
-	results := make([]scoredResult, len(notes))
-
-	for i, note := range notes {
-		score := 0
-		titleLower := strings.ToLower(note.Title)
-		contentLower := strings.ToLower(note.Content)
-
-		if titleLower == queryLower {
-			score += 100
-		} else if strings.Contains(titleLower, queryLower) {
-			score += 50
-		}
-
-		if strings.Contains(contentLower, queryLower) {
-			if strings.HasPrefix(contentLower, queryLower) {
-				score += 30
-			} else {
-				score += 10
-			}
-		}
-
-		results[i] = scoredResult{note: note, score: score}
-	}
-
-	return results
 }
 
 func getMatchPreview(content, query string) string {
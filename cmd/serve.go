@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr  string
+	serveToken string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the store as a local HTTP/JSON API",
+	Long: `Serve a REST API over the store for tools that can't shell out.
+
+Endpoints: /notes, /notes/{id}, /search, /categories and /tags. Single-note
+responses carry an ETag; send it back in If-Match to make PUT, PATCH and
+DELETE fail with 412 if the note changed. If --token (or BRAINDUMP_TOKEN) is
+set, requests must send "Authorization: Bearer <token>".
+
+Requests must name a loopback host (localhost, 127.0.0.1 or ::1), and request
+bodies must be sent as application/json.`,
+	Example: `  braindump serve
+  braindump serve --addr 127.0.0.1:7777 --token secret
+  curl -s localhost:7777/search?q=stripe`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7777", "address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", os.Getenv("BRAINDUMP_TOKEN"), "require this bearer token (default $BRAINDUMP_TOKEN)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           server.New(client, serveToken).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", storePath, serveAddr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
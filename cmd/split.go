@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var splitCmd = &cobra.Command{
+	Use:   "split <id>",
+	Short: "Split a note into several notes by heading",
+	Long: `Split a note into one note per "## " heading.
+
+Each section becomes a new note in the same category, titled after its
+heading and carrying the original tags. Text before the first heading stays
+in the original note; if there is none, the original note takes over the
+first section. New notes record the original ID in their metadata
+(split_from).`,
+	Example: `  braindump split a1b2c3d4`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSplit,
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+}
+
+func runSplit(cmd *cobra.Command, args []string) error {
+	split, err := client.Split(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to split note: %w", err)
+	}
+	if err := protect(cmd, split...); err != nil {
+		return err
+	}
+
+	return render(output{kind: kindNotes, data: asNoteList(split), text: func() {
+		fmt.Printf("✓ Split note into %d note(s):\n", len(split))
+		for _, n := range split {
+			fmt.Printf("  [%s] %s (id: %s)\n", n.Category, n.Title, idLabel(n))
+		}
+	}})
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var tagsMergeInto string
+
+var tagsRenameCmd = &cobra.Command{
+	Use:     "rename <old> <new>",
+	Short:   "Rename a tag on every note",
+	Example: `  braindump tags rename Payment payment`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runTagsRename,
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:     "merge <tag>... --into <tag>",
+	Short:   "Merge tags into one",
+	Example: `  braindump tags merge payments pay --into payment`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runTagsMerge,
+}
+
+var tagsDeleteCmd = &cobra.Command{
+	Use:     "delete <tag>",
+	Short:   "Remove a tag from every note",
+	Example: `  braindump tags delete obsolete`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runTagsDelete,
+}
+
+var tagsNormalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Apply the tag policy to existing notes",
+	Long: `Lowercase, trim and resolve aliases on the tags of every existing note.
+
+New and updated notes are normalized automatically. Aliases are configured
+in tags.yaml in the store directory:
+
+  aliases:
+    payments: payment`,
+	Args: cobra.NoArgs,
+	RunE: runTagsNormalize,
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsRenameCmd)
+	tagsCmd.AddCommand(tagsMergeCmd)
+	tagsCmd.AddCommand(tagsDeleteCmd)
+	tagsCmd.AddCommand(tagsNormalizeCmd)
+
+	tagsMergeCmd.Flags().StringVar(&tagsMergeInto, "into", "", "tag to merge into")
+	tagsMergeCmd.MarkFlagRequired("into")
+}
+
+func runTagsRename(cmd *cobra.Command, args []string) error {
+	changed, err := client.RenameTag(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	return render(output{kind: kindTagChange, data: tagChange{From: []string{args[0]}, To: args[1], Notes: changed}, text: func() {
+		fmt.Printf("✓ Renamed tag %s to %s on %d note(s)\n", args[0], args[1], changed)
+	}})
+}
+
+func runTagsMerge(cmd *cobra.Command, args []string) error {
+	// Only tags renamed on some note count as merged; the target itself and
+	// repeats of a tag, which match it whatever their case, are skipped
+	merged := []string{}
+	seen := map[string]bool{strings.ToLower(tagsMergeInto): true}
+	total := 0
+	for _, tag := range args {
+		if seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		changed, err := client.RenameTag(cmd.Context(), tag, tagsMergeInto)
+		if err != nil {
+			return fmt.Errorf("failed to merge tag %s: %w", tag, err)
+		}
+		if changed > 0 {
+			merged = append(merged, tag)
+		}
+		total += changed
+	}
+
+	return render(output{kind: kindTagChange, data: tagChange{From: merged, To: tagsMergeInto, Notes: total}, text: func() {
+		fmt.Printf("✓ Merged %d tag(s) into %s on %d note(s)\n", len(merged), tagsMergeInto, total)
+	}})
+}
+
+func runTagsDelete(cmd *cobra.Command, args []string) error {
+	changed, err := client.DeleteTag(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	return render(output{kind: kindTagChange, data: tagChange{From: []string{args[0]}, Notes: changed}, text: func() {
+		fmt.Printf("✓ Removed tag %s from %d note(s)\n", args[0], changed)
+	}})
+}
+
+func runTagsNormalize(cmd *cobra.Command, args []string) error {
+	changed, err := client.NormalizeTags(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to normalize tags: %w", err)
+	}
+
+	return render(output{kind: kindTagChange, data: tagChange{Notes: changed}, text: func() {
+		fmt.Printf("✓ Normalized tags on %d note(s)\n", changed)
+	}})
+}
@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/MohGanji/braindump/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -25,52 +28,85 @@ func init() {
 }
 
 func runCategories(cmd *cobra.Command, args []string) error {
-	categories, err := store.GetCategories()
+	counts, err := client.Categories(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to get categories: %w", err)
 	}
 
-	if len(categories) == 0 {
+	categories := make([]string, 0, len(counts))
+	for cat := range counts {
+		categories = append(categories, cat)
+	}
+	sort.Strings(categories)
+
+	result := make(categoryCounts, len(categories))
+	for i, cat := range categories {
+		result[i] = categoryCount{Category: cat, Count: counts[cat]}
+	}
+
+	return render(output{kind: kindCategories, data: result, text: func() { printCategoryTree(counts) }})
+}
+
+func printCategoryTree(counts map[string]int) {
+	if len(counts) == 0 {
 		fmt.Println("No categories found")
-		return nil
+		return
 	}
 
-	if formatFlag == "json" {
-		return outputJSON(categories)
+	// Parent categories without notes of their own still appear in the tree,
+	// and every category shows the total for its subtree.
+	totals := make(map[string]int)
+	for cat, count := range counts {
+		totals[cat] += count
+		for _, parent := range storage.ParentCategories(cat) {
+			totals[parent] += count
+		}
 	}
 
-	sort.Strings(categories)
+	// Sorting by segment keeps children under their parent: "a/b" before
+	// "a-c", although "/" sorts after "-"
+	tree := make([]string, 0, len(totals))
+	for cat := range totals {
+		tree = append(tree, cat)
+	}
+	sort.Slice(tree, func(i, j int) bool {
+		return slices.Compare(strings.Split(tree[i], "/"), strings.Split(tree[j], "/")) < 0
+	})
 
 	fmt.Println("Categories:")
-	for _, cat := range categories {
-		notes, _ := store.List(cat)
-		fmt.Printf("  %s (%d note(s))\n", cat, len(notes))
+	for _, cat := range tree {
+		depth := strings.Count(cat, "/")
+		name := cat[strings.LastIndex(cat, "/")+1:]
+		fmt.Printf("  %s%s (%d note(s))\n", strings.Repeat("  ", depth), name, totals[cat])
 	}
-
-	return nil
 }
 
 func runTags(cmd *cobra.Command, args []string) error {
-	tags, err := store.GetTags()
+	counts, err := client.Tags(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to get tags: %w", err)
 	}
 
-	if len(tags) == 0 {
-		fmt.Println("No tags found")
-		return nil
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
 	}
-
-	if formatFlag == "json" {
-		return outputJSON(tags)
-	}
-
 	sort.Strings(tags)
 
-	fmt.Println("Tags:")
-	for _, tag := range tags {
-		fmt.Printf("  %s\n", tag)
+	result := make(tagCounts, len(tags))
+	for i, tag := range tags {
+		result[i] = tagCount{Tag: tag, Count: counts[tag]}
 	}
 
-	return nil
+	return render(output{kind: kindTags, data: result, text: func() {
+		if len(result) == 0 {
+			fmt.Println("No tags found")
+			return
+		}
+
+		fmt.Println("Tags:")
+		for _, tc := range result {
+			fmt.Printf("  %s (%d note(s))\n", tc.Tag, tc.Count)
+		}
+	}})
 }
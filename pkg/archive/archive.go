@@ -0,0 +1,243 @@
+// Package archive writes portable exports of a braindump store and reads
+// them back, along with other import sources.
+//
+// A tar or zip archive holds the notes in the store's own file format, so
+// frontmatter and metadata survive the trip, along with their audit history
+// and a manifest:
+//
+//	notes/<category>/<title>.md   one file per note
+//	history.jsonl                 audit log entries of the archived notes
+//	manifest.json                 schema version, filters and a SHA-256
+//	                              checksum of every other file
+//
+// A JSONL export is one note per line, for tools that don't read archives.
+// The search index is never exported; it is rebuilt on import.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// SchemaVersion is the version of the archive layout, recorded in the
+// manifest. It only changes when the layout changes incompatibly.
+const SchemaVersion = 1
+
+// Names of the files in an archive.
+const (
+	ManifestName = "manifest.json"
+	HistoryName  = "history.jsonl"
+	NotesDir     = "notes"
+)
+
+// Format is the container an export is written as.
+type Format string
+
+// Formats.
+const (
+	// FormatTar is a tar archive, gzip-compressed if requested
+	FormatTar Format = "tar"
+	// FormatZip is a zip archive
+	FormatZip Format = "zip"
+	// FormatJSONL is one JSON note per line, without manifest or history
+	FormatJSONL Format = "jsonl"
+)
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatTar, FormatZip, FormatJSONL:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("unknown export format %q (use tar, zip, jsonl)", s)
+}
+
+// FormatFor guesses the format of a file from its name, defaulting to
+// FormatTar. compress reports whether a tar archive should be gzipped.
+func FormatFor(name string) (format Format, compress bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, false
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".ndjson"):
+		return FormatJSONL, false
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, false
+	}
+	return FormatTar, strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// File is a file listed in the manifest.
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes an archive.
+type Manifest struct {
+	Schema  int       `json:"schema"`
+	Created time.Time `json:"created"`
+	// Category and Tags are the filters the notes were selected with
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Notes    int      `json:"notes"`
+	Files    []File   `json:"files"`
+}
+
+// Writer writes an export. Notes are written as they are added; the
+// manifest is written by Close.
+type Writer struct {
+	format   Format
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	zw       *zip.Writer
+	jsonl    *json.Encoder
+	created  time.Time
+	paths    map[string]bool
+	manifest Manifest
+}
+
+// NewWriter starts an export to w. compress gzips a tar archive.
+func NewWriter(w io.Writer, format Format, compress bool) *Writer {
+	now := time.Now().UTC()
+	a := &Writer{
+		format:   format,
+		created:  now,
+		paths:    make(map[string]bool),
+		manifest: Manifest{Schema: SchemaVersion, Created: now, Files: []File{}},
+	}
+	switch format {
+	case FormatZip:
+		a.zw = zip.NewWriter(w)
+	case FormatJSONL:
+		a.jsonl = json.NewEncoder(w)
+	default:
+		if compress {
+			a.gz = gzip.NewWriter(w)
+			w = a.gz
+		}
+		a.tw = tar.NewWriter(w)
+	}
+	return a
+}
+
+// SetFilter records the filters the notes were selected with.
+func (a *Writer) SetFilter(category string, tags []string) {
+	a.manifest.Category = category
+	a.manifest.Tags = tags
+}
+
+// Add writes a note whose content as stored is body, ciphertext for
+// encrypted notes.
+func (a *Writer) Add(note *models.Note, body string) error {
+	a.manifest.Notes++
+
+	if a.jsonl != nil {
+		exported := *note
+		exported.Content = body
+		exported.Scope = ""
+		exported.Injection = nil
+		return a.jsonl.Encode(&exported)
+	}
+
+	content, err := storage.FormatNote(note, body)
+	if err != nil {
+		return fmt.Errorf("failed to format note %s: %w", note.ID, err)
+	}
+	return a.writeFile(a.notePath(note), []byte(content))
+}
+
+// AddHistory writes the audit log entries of the archived notes. JSONL
+// exports have no history.
+func (a *Writer) AddHistory(entries []audit.Entry) error {
+	if a.jsonl != nil || len(entries) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	for _, e := range entries {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return a.writeFile(HistoryName, []byte(b.String()))
+}
+
+// Close writes the manifest and finishes the archive. It doesn't close the
+// underlying writer.
+func (a *Writer) Close() (*Manifest, error) {
+	if a.jsonl != nil {
+		return &a.manifest, nil
+	}
+
+	data, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := a.writeEntry(ManifestName, append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	if a.zw != nil {
+		return &a.manifest, a.zw.Close()
+	}
+	if err := a.tw.Close(); err != nil {
+		return nil, err
+	}
+	if a.gz != nil {
+		return &a.manifest, a.gz.Close()
+	}
+	return &a.manifest, nil
+}
+
+// notePath returns a path for note that no other note in the archive has.
+// Notes with the same title in the same category, as in a project and the
+// global store, are told apart by their ID.
+func (a *Writer) notePath(note *models.Note) string {
+	p := path.Join(NotesDir, storage.NoteFile(note))
+	if a.paths[p] {
+		p = strings.TrimSuffix(p, ".md") + "-" + note.ID[:min(8, len(note.ID))] + ".md"
+	}
+	a.paths[p] = true
+	return p
+}
+
+// writeFile writes a file and lists it in the manifest.
+func (a *Writer) writeFile(name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	a.manifest.Files = append(a.manifest.Files, File{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	return a.writeEntry(name, data)
+}
+
+func (a *Writer) writeEntry(name string, data []byte) error {
+	if a.zw != nil {
+		f, err := a.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.created})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: a.created, Typeflag: tar.TypeReg}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	return err
+}
@@ -0,0 +1,301 @@
+package archive
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter keys other tools use for a note's times, in order of
+// preference.
+var (
+	createdKeys = []string{"created", "created_at", "date", "ctime"}
+	updatedKeys = []string{"updated", "updated_at", "modified", "lastmod", "mtime"}
+)
+
+// timeLayouts are the layouts tried for times written as strings. Times
+// without a zone are local.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// markdownFile is a markdown file under a directory being imported.
+type markdownFile struct {
+	// rel is the slash-separated path relative to the directory
+	rel     string
+	data    []byte
+	modTime time.Time
+}
+
+// readDir reads the markdown files under dir, such as a copy of a store, an
+// unpacked archive or a plain folder of notes.
+func readDir(dir string) (*Contents, error) {
+	files, root, err := walkMarkdown(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &Contents{}
+	for _, f := range files {
+		note, err := parseMarkdown(f, root, "markdown")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.rel, err)
+		}
+		contents.Notes = append(contents.Notes, note)
+	}
+	return contents, nil
+}
+
+// walkMarkdown reads the markdown files under dir in path order, skipping
+// hidden files and directories. root is the category for files directly in
+// dir, named after it.
+func walkMarkdown(dir string) (files []markdownFile, root string, err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	root = categoryName(filepath.Base(abs))
+	if root == "" {
+		root = "notes"
+	}
+
+	err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != abs {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(abs, p)
+		files = append(files, markdownFile{rel: filepath.ToSlash(rel), data: data, modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, root, nil
+}
+
+// parseMarkdown parses a markdown file. Files in the store's own format are
+// read as they are. Other files, with another tool's frontmatter or none at
+// all, are read leniently: the title defaults to the file name, the category
+// to the file's folder (root for files at the top), and times missing from
+// the frontmatter to the file's modification time. Their other frontmatter
+// values become metadata, along with source and the file's path.
+func parseMarkdown(f markdownFile, root, source string) (*models.Note, error) {
+	front, body, hasFront := splitFrontmatter(string(f.data))
+	if hasFront {
+		if note, err := storage.ParseNote(string(f.data)); err == nil && note.ID != "" {
+			if note.Category == "" {
+				note.Category = folderCategory(f.rel, root)
+			}
+			return note, nil
+		}
+	}
+
+	fields := make(map[string]any)
+	if hasFront {
+		var raw map[string]any
+		if err := yaml.Unmarshal([]byte(front), &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		}
+		for key, value := range raw {
+			fields[strings.ToLower(key)] = value
+		}
+	}
+
+	note := &models.Note{
+		Title:    strings.TrimSuffix(path.Base(f.rel), path.Ext(f.rel)),
+		Category: folderCategory(f.rel, root),
+		Content:  body,
+		Metadata: map[string]string{"source": source, "source_path": f.rel},
+	}
+	if id := scalar(fields["id"]); id != "" {
+		if _, err := uuid.Parse(id); err == nil {
+			note.ID = id
+		} else {
+			note.Metadata["source_id"] = id
+		}
+	}
+	if title := scalar(fields["title"]); title != "" {
+		note.Title = title
+	}
+	if category := categoryName(scalar(fields["category"])); category != "" {
+		note.Category = category
+	}
+	note.Tags = append(tagList(fields["tags"]), tagList(fields["tag"])...)
+	note.Created = firstTime(fields, createdKeys)
+	note.Updated = firstTime(fields, updatedKeys)
+	if note.Updated.IsZero() {
+		note.Updated = f.modTime
+	}
+	if note.Created.IsZero() || note.Created.After(note.Updated) {
+		note.Created = note.Updated
+	}
+
+	if meta, ok := fields["metadata"].(map[string]any); ok {
+		for key, value := range meta {
+			if s := scalar(value); s != "" {
+				note.Metadata[key] = s
+			}
+		}
+	}
+	for key, value := range fields {
+		if known(key) {
+			continue
+		}
+		if s := scalar(value); s != "" {
+			note.Metadata[key] = s
+		}
+	}
+	return note, nil
+}
+
+// splitFrontmatter splits markdown into its YAML frontmatter and its body.
+// hasFront is false for markdown without frontmatter, whose body is all of
+// it. Windows line endings are accepted.
+func splitFrontmatter(content string) (front, body string, hasFront bool) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return "", strings.TrimSpace(content), false
+	}
+
+	rest := "\n" + content[4:]
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n---") {
+			return "", strings.TrimSpace(content), false
+		}
+		end = len(rest) - 4
+	}
+	front = rest[:end]
+	body = strings.TrimPrefix(rest[end+4:], "\n")
+	return strings.TrimPrefix(front, "\n"), strings.TrimSpace(body), true
+}
+
+// known reports whether a frontmatter key is read into a note field rather
+// than its metadata.
+func known(key string) bool {
+	switch key {
+	case "id", "title", "category", "tags", "tag", "metadata":
+		return true
+	}
+	return slices.Contains(createdKeys, key) || slices.Contains(updatedKeys, key)
+}
+
+// folderCategory returns the category of the file at rel, named after its
+// folder.
+func folderCategory(rel, root string) string {
+	if category := categoryName(path.Dir(rel)); category != "" && category != "." {
+		return category
+	}
+	return root
+}
+
+// categoryName turns a folder name into a valid category. Spaces become "-"
+// and characters categories can't contain are dropped, so "Team Notes/Q&A"
+// becomes "Team-Notes/QA".
+func categoryName(name string) string {
+	var segments []string
+	for _, segment := range strings.Split(storage.CleanCategory(name), "/") {
+		segment = strings.Join(strings.Fields(segment), "-")
+		segment = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+				return r
+			}
+			return -1
+		}, segment)
+		segment = strings.Trim(segment, ".-")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// scalar returns a frontmatter value as a string, or "" if it is a list or
+// a map.
+func scalar(value any) string {
+	switch v := value.(type) {
+	case nil, []any, map[string]any:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// tagList reads tags written as a list or as a string separated by commas
+// or spaces, with or without a leading "#".
+func tagList(value any) []string {
+	var raw []string
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			raw = append(raw, scalar(item))
+		}
+	case string:
+		raw = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	}
+
+	var tags []string
+	for _, tag := range raw {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// firstTime returns the first of keys in fields that holds a time.
+func firstTime(fields map[string]any, keys []string) time.Time {
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
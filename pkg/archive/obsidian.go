@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// inlineTag matches an Obsidian #tag, which must follow whitespace or
+	// start a line and can't be all digits
+	inlineTag = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	// wikilink matches [[target]], [[target#heading]] and [[target|alias]],
+	// capturing the target
+	wikilink   = regexp.MustCompile(`\[\[([^\[\]|#^]+)[^\[\]]*\]\]`)
+	inlineCode = regexp.MustCompile("`[^`\n]*`")
+)
+
+// ReadVault reads the notes of an Obsidian vault. Notes are read like a
+// plain folder of markdown files (see Read), and additionally:
+//
+//   - #tags in the text are added to the frontmatter tags
+//   - [[wikilinks]] are kept in the content as written, and the IDs of the
+//     vault notes they point to are listed in the "links" metadata,
+//     separated by commas
+//   - notes without an ID get one derived from their path in the vault, so
+//     importing the vault again finds the same notes
+//
+// Hidden folders, such as .obsidian and .trash, and attachments are skipped.
+func ReadVault(dir string) (*Contents, error) {
+	files, root, err := walkMarkdown(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &Contents{}
+	// Obsidian resolves a link by path, or by file name if it is unique
+	byPath := make(map[string]string)
+	byName := make(map[string]string)
+	for _, f := range files {
+		note, err := parseMarkdown(f, root, "obsidian")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f.rel, err)
+		}
+		if note.ID == "" {
+			note.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("obsidian:"+f.rel)).String()
+		}
+		note.Tags = append(note.Tags, inlineTags(note.Content)...)
+		contents.Notes = append(contents.Notes, note)
+
+		key := strings.ToLower(strings.TrimSuffix(f.rel, path.Ext(f.rel)))
+		byPath[key] = note.ID
+		if name := path.Base(key); byName[name] == "" {
+			byName[name] = note.ID
+		}
+	}
+
+	for _, note := range contents.Notes {
+		var links []string
+		seen := make(map[string]bool)
+		for _, target := range wikilinks(note.Content) {
+			target = strings.ToLower(strings.TrimSuffix(target, ".md"))
+			id, ok := byPath[strings.TrimPrefix(target, "/")]
+			if !ok {
+				id, ok = byName[path.Base(target)]
+			}
+			if ok && id != note.ID && !seen[id] {
+				seen[id] = true
+				links = append(links, id)
+			}
+		}
+		if len(links) > 0 {
+			note.Metadata["links"] = strings.Join(links, ",")
+		}
+	}
+	return contents, nil
+}
+
+// inlineTags returns the #tags in markdown text, outside code.
+func inlineTags(text string) []string {
+	var tags []string
+	for _, line := range prose(text) {
+		for _, m := range inlineTag.FindAllStringSubmatch(line, -1) {
+			if tag := strings.Trim(m[1], "/"); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// wikilinks returns the targets of the [[wikilinks]] in markdown text,
+// outside code.
+func wikilinks(text string) []string {
+	var targets []string
+	for _, line := range prose(text) {
+		for _, m := range wikilink.FindAllStringSubmatch(line, -1) {
+			if target := strings.TrimSpace(m[1]); target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// prose returns the lines of markdown text outside fenced code blocks, with
+// inline code removed.
+func prose(text string) []string {
+	var lines []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced {
+			lines = append(lines, inlineCode.ReplaceAllString(line, ""))
+		}
+	}
+	return lines
+}
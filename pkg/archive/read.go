@@ -0,0 +1,185 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// ErrChecksum is returned when a file in an archive doesn't match the
+// checksum in its manifest.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// Contents are the notes read from an import source.
+type Contents struct {
+	// Manifest is nil for sources without one, such as JSONL files and
+	// directories
+	Manifest *Manifest
+	Notes    []*models.Note
+}
+
+// Read reads notes from a JSONL file, a tar archive, gzipped or not, a zip
+// archive, or a directory of markdown files. The kind of file is detected
+// from its contents. Archive files are checked against the manifest's
+// checksums before any note is parsed. Markdown files in a directory don't
+// need to be in the store's format, or to have frontmatter at all.
+func Read(name string) (*Contents, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readDir(name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(512)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return readZip(f, info.Size())
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTar(gz)
+	case len(magic) > 262 && string(magic[257:262]) == "ustar":
+		return readTar(r)
+	}
+	return readJSONL(r)
+}
+
+func readJSONL(r io.Reader) (*Contents, error) {
+	decoder := json.NewDecoder(r)
+	contents := &Contents{}
+	for line := 1; ; line++ {
+		var note models.Note
+		err := decoder.Decode(&note)
+		if err == io.EOF {
+			return contents, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse note %d: %w", line, err)
+		}
+		note.Scope = ""
+		note.Injection = nil
+		contents.Notes = append(contents.Notes, &note)
+	}
+}
+
+func readTar(r io.Reader) (*Contents, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[path.Clean(header.Name)] = data
+	}
+	return fromFiles(files)
+}
+
+func readZip(r io.ReaderAt, size int64) (*Contents, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		files[path.Clean(f.Name)] = data
+	}
+	return fromFiles(files)
+}
+
+// fromFiles verifies archive files against the manifest and parses the
+// notes among them.
+func fromFiles(files map[string][]byte) (*Contents, error) {
+	contents := &Contents{}
+
+	if data, ok := files[ManifestName]; ok {
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if manifest.Schema > SchemaVersion {
+			return nil, fmt.Errorf("archive schema %d is newer than this version of braindump supports (%d)", manifest.Schema, SchemaVersion)
+		}
+		for _, f := range manifest.Files {
+			data, ok := files[path.Clean(f.Path)]
+			if !ok {
+				return nil, fmt.Errorf("archive is missing %s, listed in its manifest", f.Path)
+			}
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != f.SHA256 {
+				return nil, fmt.Errorf("%w: %s", ErrChecksum, f.Path)
+			}
+		}
+		contents.Manifest = &manifest
+	}
+
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(name, NotesDir+"/") && strings.HasSuffix(name, ".md") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		note, err := storage.ParseNote(string(files[name]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if note.Category == "" {
+			note.Category = path.Dir(strings.TrimPrefix(name, NotesDir+"/"))
+		}
+		contents.Notes = append(contents.Notes, note)
+	}
+	return contents, nil
+}
@@ -0,0 +1,149 @@
+// Package audit records who saw and changed notes in an append-only log.
+//
+// The log is a file of JSON lines, one Entry per line, so it can be read
+// with standard tools and appended to without rewriting it.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the log in a store's index directory.
+const FileName = "audit.log"
+
+// Actions recorded in the log.
+const (
+	ActionAdd    = "add"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionMove   = "move"
+	// ActionReveal means a sensitive note's content was shown
+	ActionReveal = "reveal"
+)
+
+// Entry is one logged event.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Actor is who made the change, as given by --actor or BRAINDUMP_ACTOR
+	Actor    string `json:"actor,omitempty"`
+	ID       string `json:"id"`
+	Category string `json:"category,omitempty"`
+	Title    string `json:"title,omitempty"`
+	// Before and After are hashes of the stored content (see Hash)
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// Command is the command that caused the event
+	Command string `json:"command,omitempty"`
+}
+
+// Hash returns the SHA-256 of content in hex, or "" for no content.
+func Hash(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Log is an audit log file.
+type Log struct {
+	path string
+}
+
+// Open returns the log in directory dir. The file is created on the first
+// write.
+func Open(dir string) *Log {
+	return &Log{path: filepath.Join(dir, FileName)}
+}
+
+// Path returns the location of the log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes entries to the end of the log, stamping those without a
+// time with the current time.
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	now := time.Now().UTC()
+	encoder := json.NewEncoder(f)
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		if err := encoder.Encode(e); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Filter selects entries from a log. Zero fields match everything.
+type Filter struct {
+	// ID matches entries for notes whose ID starts with it
+	ID     string
+	Actor  string
+	Action string
+	Since  time.Time
+}
+
+func (f Filter) match(e Entry) bool {
+	return strings.HasPrefix(e.ID, f.ID) &&
+		(f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		!e.Time.Before(f.Since)
+}
+
+// Read returns the entries of logs matching filter, oldest first. Missing
+// logs are empty and lines that can't be parsed are skipped.
+func Read(logs []*Log, filter Filter) ([]Entry, error) {
+	entries := []Entry{}
+	for _, l := range logs {
+		f, err := os.Open(l.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue
+			}
+			if filter.match(e) {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
@@ -0,0 +1,22 @@
+package braindump
+
+import (
+	"context"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// AuditLog returns the audit log entries of the client's stores matching
+// filter, oldest first. Stores that keep no log contribute nothing.
+func (c *Client) AuditLog(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+
+	store, ok := c.store.(storage.Audited)
+	if !ok {
+		return []audit.Entry{}, nil
+	}
+	return audit.Read(store.AuditLogs(), filter)
+}
@@ -0,0 +1,227 @@
+// Package braindump is the public Go API for a braindump store.
+//
+// It wraps a storage.Store with the lookup and ranking rules the CLI uses:
+// notes can be referenced by full ID, unique ID prefix or exact title, and
+// search results are ranked by how well the title and content match.
+//
+//	client, err := braindump.New(braindump.WithPath("/path/to/notes"))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	results, err := client.Search(ctx, "stripe", braindump.SearchFilter{Category: "api-creds"})
+package braindump
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// Client reads and writes notes in a store. It is not safe for concurrent
+// use; callers that share a Client between goroutines must serialize writes.
+type Client struct {
+	store         storage.Store
+	ownsStore     bool
+	secrets       secretGuard
+	sensitiveTags []string
+}
+
+type options struct {
+	path          string
+	projectPath   string
+	addGlobal     bool
+	tagDefaults   *storage.TagPolicy
+	passphrase    string
+	actor         string
+	readOnly      bool
+	secrets       secretGuard
+	sensitiveTags []string
+	store         storage.Store
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+// WithPath opens the file store at path. It defaults to DefaultPath.
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithProjectPath layers the file store at path over the global store. New
+// notes go to the project store, and reads merge both, labeling each note
+// with its scope.
+func WithProjectPath(path string) Option {
+	return func(o *options) {
+		o.projectPath = path
+	}
+}
+
+// WithGlobalAdds sends new notes to the global store when a project store
+// is layered over it. Reads still merge both.
+func WithGlobalAdds() Option {
+	return func(o *options) {
+		o.addGlobal = true
+	}
+}
+
+// WithTagDefaults adds tag normalization rules to the file stores the
+// client opens. Each store's own tags.yaml takes precedence.
+func WithTagDefaults(policy storage.TagPolicy) Option {
+	return func(o *options) {
+		o.tagDefaults = &policy
+	}
+}
+
+// WithPassphrase encrypts and decrypts notes in the file stores the client
+// opens with passphrase. Notes are encrypted if they are in a category listed
+// in the store's encryption.yaml or were added with the encrypted metadata
+// set.
+func WithPassphrase(passphrase string) Option {
+	return func(o *options) {
+		o.passphrase = passphrase
+	}
+}
+
+// WithActor attributes changes made through the client to actor in the
+// audit logs of the file stores it opens.
+func WithActor(actor string) Option {
+	return func(o *options) {
+		o.actor = actor
+	}
+}
+
+// WithReadOnly makes every change through the client fail with
+// storage.ErrForbidden.
+func WithReadOnly() Option {
+	return func(o *options) {
+		o.readOnly = true
+	}
+}
+
+// WithStore uses an already opened store. The caller keeps ownership and
+// Close does not close it.
+func WithStore(store storage.Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// New returns a client for the store selected by opts.
+func New(opts ...Option) (*Client, error) {
+	o := options{path: DefaultPath()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.store != nil {
+		return &Client{store: o.store, secrets: o.secrets, sensitiveTags: o.sensitiveTags}, nil
+	}
+
+	store, err := o.open(o.path)
+	if err != nil {
+		return nil, err
+	}
+	if o.projectPath == "" || samePath(o.projectPath, o.path) {
+		return &Client{store: store, ownsStore: true, secrets: o.secrets, sensitiveTags: o.sensitiveTags}, nil
+	}
+
+	project, err := o.open(o.projectPath)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	layered := storage.NewLayeredStore(project, store)
+	if o.addGlobal {
+		layered.SetAddScope(storage.ScopeGlobal)
+	}
+	return &Client{store: layered, ownsStore: true, secrets: o.secrets, sensitiveTags: o.sensitiveTags}, nil
+}
+
+// open opens the file store at path, guarded by its permissions.yaml and
+// the read-only option.
+func (o *options) open(path string) (storage.Store, error) {
+	store, err := storage.NewFileStore(path)
+	if err != nil {
+		return nil, err
+	}
+	if o.tagDefaults != nil {
+		store.ApplyTagDefaults(*o.tagDefaults)
+	}
+	if o.passphrase != "" {
+		store.SetPassphrase(o.passphrase)
+	}
+	store.SetActor(o.actor)
+
+	policy, err := storage.LoadWritePolicy(filepath.Join(path, "permissions.yaml"))
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	if o.readOnly || !policy.Empty() {
+		return storage.NewGuardedStore(store, o.readOnly, policy, o.actor), nil
+	}
+	return store, nil
+}
+
+// ProjectDir is the name of the directory holding a project store.
+const ProjectDir = ".braindump"
+
+// FindProjectStore looks for a project store in dir and its parents. The
+// global store at DefaultPath doesn't count, so a home directory isn't
+// mistaken for a project.
+func FindProjectStore(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for d := abs; ; d = filepath.Dir(d) {
+		candidate := filepath.Join(d, ProjectDir)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && !samePath(candidate, DefaultPath()) {
+			return candidate, true
+		}
+		if filepath.Dir(d) == d {
+			return "", false
+		}
+	}
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// DefaultPath is the store used when no path is given: ~/.braindump.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".braindump"
+	}
+	return filepath.Join(home, ".braindump")
+}
+
+// Store returns the underlying store.
+func (c *Client) Store() storage.Store {
+	return c.store
+}
+
+// Close releases the store if the client opened it.
+func (c *Client) Close() error {
+	if !c.ownsStore {
+		return nil
+	}
+	return c.store.Close()
+}
+
+// check reports a cancelled context before a store call is made. Store calls
+// are local and short, so they are not interrupted once started.
+func check(ctx context.Context) error {
+	return ctx.Err()
+}
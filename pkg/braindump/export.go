@@ -0,0 +1,67 @@
+package braindump
+
+import (
+	"context"
+	"io"
+
+	"github.com/MohGanji/braindump/pkg/archive"
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// ExportOptions select the notes to export and how they are written.
+type ExportOptions struct {
+	Format archive.Format
+	// Compress gzips a tar archive
+	Compress bool
+	// Category limits the export to a category and its subcategories
+	Category string
+	// Tags limits the export to notes carrying any of these tags
+	Tags []string
+}
+
+// Export writes the selected notes to w, with their audit history, and
+// returns the archive's manifest. Encrypted notes are exported encrypted.
+func (c *Client) Export(ctx context.Context, w io.Writer, opts ExportOptions) (*archive.Manifest, error) {
+	notes, err := c.List(ctx, ListFilter{Category: opts.Category, Tags: opts.Tags})
+	if err != nil {
+		return nil, err
+	}
+
+	out := archive.NewWriter(w, opts.Format, opts.Compress)
+	out.SetFilter(storage.CleanCategory(opts.Category), opts.Tags)
+
+	sealer, _ := c.store.(storage.Sealer)
+	ids := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+		body := note.Content
+		if sealer != nil {
+			if body, err = sealer.Seal(note); err != nil {
+				return nil, err
+			}
+		}
+		if err := out.Add(note, body); err != nil {
+			return nil, err
+		}
+		ids[note.ID] = true
+	}
+
+	entries, err := c.AuditLog(ctx, audit.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	var history []audit.Entry
+	for _, e := range entries {
+		if ids[e.ID] {
+			history = append(history, e)
+		}
+	}
+	if err := out.AddHistory(history); err != nil {
+		return nil, err
+	}
+
+	return out.Close()
+}
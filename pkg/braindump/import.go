@@ -0,0 +1,259 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// ConflictPolicy decides what happens to an imported note whose ID, or whose
+// category and title, is taken by a note already in the store or imported
+// before it.
+type ConflictPolicy string
+
+// Conflict policies.
+const (
+	// ConflictSkip keeps the note already there
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces it with the imported note
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename imports the note under a new ID, and a new title if its
+	// title is taken
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictNewer keeps whichever note was updated last
+	ConflictNewer ConflictPolicy = "newer"
+)
+
+// ParseConflictPolicy returns the policy named s. An empty string is
+// ConflictSkip.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch ConflictPolicy(s) {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictNewer:
+		return ConflictPolicy(s), nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (use skip, overwrite, rename, newer)", s)
+}
+
+// ImportOptions control Import.
+type ImportOptions struct {
+	OnConflict ConflictPolicy
+	// DryRun reports what would be imported without changing the store
+	DryRun bool
+}
+
+// What Import did with a note.
+const (
+	ImportAdd       = "add"
+	ImportOverwrite = "overwrite"
+	ImportRename    = "rename"
+	ImportSkip      = "skip"
+)
+
+// ImportConflict is an imported note that collided with another.
+type ImportConflict struct {
+	ID       string `json:"id" yaml:"id"`
+	Category string `json:"category" yaml:"category"`
+	Title    string `json:"title" yaml:"title"`
+	// With is the ID of the note it collided with
+	With string `json:"with" yaml:"with"`
+	// Action is what was done with the imported note
+	Action string `json:"action" yaml:"action"`
+	// NewID and NewTitle are set when the note was renamed
+	NewID    string `json:"new_id,omitempty" yaml:"new_id,omitempty"`
+	NewTitle string `json:"new_title,omitempty" yaml:"new_title,omitempty"`
+}
+
+// ImportReport summarizes an import.
+type ImportReport struct {
+	DryRun      bool             `json:"dry_run" yaml:"dry_run"`
+	Read        int              `json:"read" yaml:"read"`
+	Added       int              `json:"added" yaml:"added"`
+	Overwritten int              `json:"overwritten" yaml:"overwritten"`
+	Renamed     int              `json:"renamed" yaml:"renamed"`
+	Skipped     int              `json:"skipped" yaml:"skipped"`
+	Conflicts   []ImportConflict `json:"conflicts" yaml:"conflicts"`
+}
+
+// planned is a note already in the store or due to be imported.
+type planned struct {
+	note *models.Note
+	// imported is set for notes in the import, with what is done with them
+	imported bool
+	action   string
+}
+
+// Import adds notes to the store, resolving collisions with opts.OnConflict.
+// A note collides with another that has its ID or, since the title names
+// the note's file, its category and title. IDs must be UUIDs; notes without
+// one get a new one, and missing times default to now.
+//
+// All notes are checked before any is written, and the store writes them in
+// one batch, so a failure imports nothing.
+func (c *Client) Import(ctx context.Context, notes []*models.Note, opts ImportOptions) (*ImportReport, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = ConflictSkip
+	}
+
+	existing, err := c.store.List("")
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*planned, len(existing))
+	byFile := make(map[string]*planned, len(existing))
+	for _, note := range existing {
+		p := &planned{note: note}
+		byID[note.ID] = p
+		byFile[storage.NoteFile(note)] = p
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Read: len(notes), Conflicts: []ImportConflict{}}
+	var queue []*planned
+	var replace []string
+	drop := func(p *planned) {
+		delete(byID, p.note.ID)
+		delete(byFile, storage.NoteFile(p.note))
+		if p.imported {
+			p.action = ImportSkip
+		} else {
+			replace = append(replace, p.note.ID)
+		}
+	}
+
+	now := time.Now()
+	for _, note := range notes {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+		if note.ID == "" {
+			note.ID = uuid.New().String()
+		} else if _, err := uuid.Parse(note.ID); err != nil {
+			return nil, fmt.Errorf("note %q has an invalid ID %q (leave it empty to have one assigned)", note.Title, note.ID)
+		}
+		if note.Created.IsZero() {
+			note.Created = now
+		}
+		if note.Updated.IsZero() {
+			note.Updated = note.Created
+		}
+		if note.Metadata == nil {
+			note.Metadata = make(map[string]string)
+		}
+		note.Category = storage.CleanCategory(note.Category)
+		if err := validate(note); err != nil {
+			return nil, fmt.Errorf("note %s (%q): %w", note.ID, note.Title, err)
+		}
+		if err := c.secrets.check(note); err != nil {
+			return nil, err
+		}
+
+		var taken []*planned
+		if p, ok := byID[note.ID]; ok {
+			taken = append(taken, p)
+		}
+		if p, ok := byFile[storage.NoteFile(note)]; ok && p.note.ID != note.ID {
+			taken = append(taken, p)
+		}
+
+		action := ImportAdd
+		if len(taken) > 0 {
+			action = resolveConflict(policy, note, taken)
+			conflict := ImportConflict{ID: note.ID, Category: note.Category, Title: note.Title, With: taken[0].note.ID, Action: action}
+
+			switch action {
+			case ImportSkip:
+				report.Conflicts = append(report.Conflicts, conflict)
+				continue
+			case ImportOverwrite:
+				for _, p := range taken {
+					drop(p)
+				}
+			case ImportRename:
+				if err := c.rename(note, byID, byFile); err != nil {
+					return nil, err
+				}
+				conflict.NewID, conflict.NewTitle = note.ID, note.Title
+			}
+			report.Conflicts = append(report.Conflicts, conflict)
+		}
+
+		p := &planned{note: note, imported: true, action: action}
+		byID[note.ID] = p
+		byFile[storage.NoteFile(note)] = p
+		queue = append(queue, p)
+	}
+
+	var batch []*models.Note
+	for _, p := range queue {
+		switch p.action {
+		case ImportAdd:
+			report.Added++
+		case ImportOverwrite:
+			report.Overwritten++
+		case ImportRename:
+			report.Renamed++
+		default:
+			continue
+		}
+		batch = append(batch, p.note)
+	}
+	report.Skipped = report.Read - len(batch)
+
+	if opts.DryRun || (len(batch) == 0 && len(replace) == 0) {
+		return report, nil
+	}
+	if err := storage.ImportNotes(c.store, batch, replace); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// resolveConflict returns what policy does with note, which collides with
+// the notes in taken.
+func resolveConflict(policy ConflictPolicy, note *models.Note, taken []*planned) string {
+	switch policy {
+	case ConflictOverwrite:
+		return ImportOverwrite
+	case ConflictRename:
+		return ImportRename
+	case ConflictNewer:
+		for _, p := range taken {
+			if !note.Updated.After(p.note.Updated) {
+				return ImportSkip
+			}
+		}
+		return ImportOverwrite
+	}
+	return ImportSkip
+}
+
+// rename gives note a free ID and title. Encrypted content is bound to the
+// note's ID, so it is decrypted first and sealed again under the new one.
+func (c *Client) rename(note *models.Note, byID, byFile map[string]*planned) error {
+	if _, ok := byID[note.ID]; ok {
+		if sealer, ok := c.store.(storage.Sealer); ok {
+			if err := sealer.Unseal(note); err != nil {
+				return fmt.Errorf("note %s (%q) is encrypted and can't be renamed without its key: %w", note.ID, note.Title, err)
+			}
+		}
+		note.ID = uuid.New().String()
+	}
+
+	title := note.Title
+	for i := 2; ; i++ {
+		if _, ok := byFile[storage.NoteFile(note)]; !ok {
+			return nil
+		}
+		note.Title = fmt.Sprintf("%s (%d)", strings.TrimSpace(title), i)
+	}
+}
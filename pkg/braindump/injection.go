@@ -0,0 +1,67 @@
+package braindump
+
+import (
+	"context"
+
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// ScanInjection returns the prompt-injection patterns in the titles and
+// content of the notes of category and its subcategories, or of every note
+// if category is empty. Findings in a title are on line 0. Encrypted notes
+// are scanned too when the client has their key.
+func (c *Client) ScanInjection(ctx context.Context, category string) ([]NoteFindings, error) {
+	notes, err := c.List(ctx, ListFilter{Category: category})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []NoteFindings{}
+	for _, note := range notes {
+		findings := scanNote(note)
+		if len(findings) == 0 {
+			continue
+		}
+		report := newNoteFindings(note)
+		for _, f := range findings {
+			report.Findings = append(report.Findings, Finding{Rule: f.Rule, Line: f.Line, Preview: f.Excerpt})
+		}
+		reports = append(reports, report)
+	}
+
+	sortFindings(reports)
+	return reports, nil
+}
+
+// scanNote returns the prompt-injection patterns in note's title, on line
+// 0, followed by those in its content.
+func scanNote(note *models.Note) []injection.Finding {
+	findings := injection.Scan(note.Title)
+	for i := range findings {
+		findings[i].Line = 0
+	}
+	return append(findings, injection.Scan(note.Content)...)
+}
+
+// GuardInjection applies mode to notes about to be shown to a model. Notes
+// with an instruction-like title or content get the names of the patterns
+// found in Injection, and with injection.ModeWrap their content is also
+// wrapped in data delimiters, with hidden characters stripped from it and
+// the title.
+func GuardInjection(notes []*models.Note, mode injection.Mode) {
+	if mode == "" || mode == injection.ModeOff {
+		return
+	}
+	for _, note := range notes {
+		findings := scanNote(note)
+		if len(findings) == 0 {
+			continue
+		}
+		note.Injection = injection.Rules(findings)
+		if mode == injection.ModeWrap {
+			note.Title = injection.StripHidden(note.Title)
+			note.Content = injection.Wrap(note.Content)
+		}
+	}
+}
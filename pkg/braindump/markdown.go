@@ -0,0 +1,27 @@
+package braindump
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// Markdown renders a note as a markdown document headed by its title, with
+// its ID, category, scope, tags and update time listed before the content.
+func Markdown(note *models.Note) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", note.Title)
+	fmt.Fprintf(&b, "- id: %s\n", note.ID)
+	fmt.Fprintf(&b, "- category: %s\n", note.Category)
+	if note.Scope != "" {
+		fmt.Fprintf(&b, "- scope: %s\n", note.Scope)
+	}
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, "- tags: %s\n", strings.Join(note.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "- updated: %s\n\n", note.Updated.Format("2006-01-02 15:04:05"))
+	b.WriteString(note.Content)
+	b.WriteString("\n")
+	return b.String()
+}
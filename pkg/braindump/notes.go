@@ -0,0 +1,272 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// AmbiguousError is returned when a reference matches more than one note.
+type AmbiguousError struct {
+	// Ref is the ID prefix or title that was looked up
+	Ref string
+	// ByTitle is true if Ref matched titles rather than ID prefixes
+	ByTitle bool
+	// Matches are the candidate notes
+	Matches []*models.Note
+}
+
+// Unwrap lets errors.Is match storage.ErrAmbiguous.
+func (e *AmbiguousError) Unwrap() error {
+	return storage.ErrAmbiguous
+}
+
+func (e *AmbiguousError) Error() string {
+	if e.ByTitle {
+		return fmt.Sprintf("multiple notes found with title %q", e.Ref)
+	}
+	return fmt.Sprintf("multiple notes found with ID prefix %q", e.Ref)
+}
+
+// ListFilter selects notes for List.
+type ListFilter struct {
+	// Category limits results to a category and its subcategories
+	Category string
+	// NoRecurse excludes subcategories of Category
+	NoRecurse bool
+	// Title keeps notes whose title contains it, ignoring case
+	Title string
+	// Tags keeps notes carrying any of these tags
+	Tags []string
+}
+
+// Edit describes changes to a note. Nil fields are left unchanged.
+type Edit struct {
+	Category *string   `json:"category,omitempty"`
+	Title    *string   `json:"title,omitempty"`
+	Content  *string   `json:"content,omitempty"`
+	Tags     *[]string `json:"tags,omitempty"`
+	// Append is added to the end of the content on a new line
+	Append *string `json:"append,omitempty"`
+}
+
+// Empty reports whether the edit changes nothing.
+func (e Edit) Empty() bool {
+	return e.Category == nil && e.Title == nil && e.Content == nil && e.Tags == nil && e.Append == nil
+}
+
+// Apply makes the changes to note and bumps its Updated time.
+func (e Edit) Apply(note *models.Note) {
+	if e.Category != nil {
+		note.Category = *e.Category
+	}
+	if e.Title != nil {
+		note.Title = *e.Title
+	}
+	if e.Content != nil {
+		note.Content = *e.Content
+	}
+	if e.Append != nil {
+		note.Content = note.Content + "\n" + *e.Append
+	}
+	if e.Tags != nil {
+		note.Tags = *e.Tags
+	}
+	note.Updated = time.Now()
+}
+
+// Add stores a new note.
+func (c *Client) Add(ctx context.Context, note *models.Note) error {
+	if err := check(ctx); err != nil {
+		return err
+	}
+	if err := validate(note); err != nil {
+		return err
+	}
+	if err := c.secrets.check(note); err != nil {
+		return err
+	}
+	return c.store.Add(note)
+}
+
+// Get returns the note with exactly this ID.
+func (c *Client) Get(ctx context.Context, id string) (*models.Note, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	return c.store.Get(id)
+}
+
+// Resolve finds a note by full ID, then by unique ID prefix, then by exact
+// title. If the reference matches several notes it returns an
+// *AmbiguousError listing them.
+func (c *Client) Resolve(ctx context.Context, idOrTitle string) (*models.Note, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	if idOrTitle == "" {
+		return nil, fmt.Errorf("note ID or title is required")
+	}
+
+	note, err := c.store.Get(idOrTitle)
+	if err == nil {
+		return note, nil
+	}
+
+	notes, err := c.store.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for note: %w", err)
+	}
+
+	var idMatches []*models.Note
+	var titleMatches []*models.Note
+
+	for _, n := range notes {
+		if strings.HasPrefix(n.ID, idOrTitle) {
+			idMatches = append(idMatches, n)
+		}
+		if n.Title == idOrTitle {
+			titleMatches = append(titleMatches, n)
+		}
+	}
+
+	if len(idMatches) == 1 {
+		return idMatches[0], nil
+	}
+
+	if len(idMatches) > 1 {
+		return nil, &AmbiguousError{Ref: idOrTitle, Matches: idMatches}
+	}
+
+	if len(titleMatches) == 0 {
+		return nil, fmt.Errorf("note %w: %s", storage.ErrNotFound, idOrTitle)
+	}
+
+	if len(titleMatches) == 1 {
+		return titleMatches[0], nil
+	}
+
+	return nil, &AmbiguousError{Ref: idOrTitle, ByTitle: true, Matches: titleMatches}
+}
+
+// List returns the notes matching filter.
+func (c *Client) List(ctx context.Context, filter ListFilter) ([]*models.Note, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+
+	category := storage.CleanCategory(filter.Category)
+	notes, err := c.store.List(category)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []*models.Note
+	titleLower := strings.ToLower(filter.Title)
+	for _, note := range notes {
+		if filter.NoRecurse && category != "" && note.Category != category {
+			continue
+		}
+		if filter.Title != "" && !strings.Contains(strings.ToLower(note.Title), titleLower) {
+			continue
+		}
+		if len(filter.Tags) > 0 && !hasAnyTag(note.Tags, filter.Tags) {
+			continue
+		}
+		filtered = append(filtered, note)
+	}
+
+	return filtered, nil
+}
+
+// Update writes a changed note back to the store.
+func (c *Client) Update(ctx context.Context, note *models.Note) error {
+	if err := check(ctx); err != nil {
+		return err
+	}
+	if err := validate(note); err != nil {
+		return err
+	}
+	if err := c.secrets.check(note); err != nil {
+		return err
+	}
+	return c.store.Update(note)
+}
+
+// Edit resolves a note, applies edit to it and stores the result.
+func (c *Client) Edit(ctx context.Context, idOrTitle string, edit Edit) (*models.Note, error) {
+	if edit.Empty() {
+		return nil, fmt.Errorf("nothing to change")
+	}
+
+	note, err := c.Resolve(ctx, idOrTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	edit.Apply(note)
+	if err := c.Update(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Delete resolves a note and removes it, returning the deleted note.
+func (c *Client) Delete(ctx context.Context, idOrTitle string) (*models.Note, error) {
+	note, err := c.Resolve(ctx, idOrTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.Delete(note.ID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Move resolves a note and relocates it to category, returning the moved
+// note.
+func (c *Client) Move(ctx context.Context, idOrTitle, category string) (*models.Note, error) {
+	note, err := c.Resolve(ctx, idOrTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Category == storage.CleanCategory(category) {
+		return nil, fmt.Errorf("%w: note is already in %s", storage.ErrConflict, note.Category)
+	}
+
+	if err := c.store.Move(note.ID, category); err != nil {
+		return nil, err
+	}
+	return c.store.Get(note.ID)
+}
+
+func validate(note *models.Note) error {
+	if err := storage.ValidateCategory(note.Category); err != nil {
+		return err
+	}
+	if strings.TrimSpace(note.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	// A masked note written back would lose its content
+	if note.Content == MaskedContent {
+		return fmt.Errorf("content is the placeholder of a masked note; reveal the note to edit it")
+	}
+	return nil
+}
+
+func hasAnyTag(noteTags []string, tags []string) bool {
+	for _, tag := range tags {
+		for _, noteTag := range noteTags {
+			if strings.EqualFold(noteTag, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
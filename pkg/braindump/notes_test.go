@@ -0,0 +1,67 @@
+package braindump
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+func TestResolve(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	for _, n := range []struct{ id, category, title string }{
+		{"aaaa1111-0000-4000-8000-000000000001", "ops", "Deploys"},
+		{"aaaa2222-0000-4000-8000-000000000002", "ops", "Backups"},
+		{"bbbb1111-0000-4000-8000-000000000003", "dev", "Backups"},
+		// A title that is also a prefix of another note's ID
+		{"cccc1111-0000-4000-8000-000000000004", "dev", "bbbb"},
+	} {
+		note := models.NewNote(n.category, n.title, "content", nil)
+		note.ID = n.id
+		if err := c.Add(ctx, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref       string
+		wantID    string
+		wantErr   error
+		byTitle   bool
+		wantCount int
+	}{
+		{ref: "aaaa1111-0000-4000-8000-000000000001", wantID: "aaaa1111-0000-4000-8000-000000000001"},
+		{ref: "aaaa2", wantID: "aaaa2222-0000-4000-8000-000000000002"},
+		{ref: "Deploys", wantID: "aaaa1111-0000-4000-8000-000000000001"},
+		{ref: "aaaa", wantErr: storage.ErrAmbiguous, wantCount: 2},
+		{ref: "Backups", wantErr: storage.ErrAmbiguous, byTitle: true, wantCount: 2},
+		{ref: "bbbb", wantID: "bbbb1111-0000-4000-8000-000000000003"},
+		{ref: "deploys", wantErr: storage.ErrNotFound},
+		{ref: "zzzz", wantErr: storage.ErrNotFound},
+	}
+	for _, tt := range tests {
+		note, err := c.Resolve(ctx, tt.ref)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Resolve(%q): got %v, want %v", tt.ref, err, tt.wantErr)
+				continue
+			}
+			var ambiguous *AmbiguousError
+			if errors.As(err, &ambiguous) && (ambiguous.ByTitle != tt.byTitle || len(ambiguous.Matches) != tt.wantCount) {
+				t.Errorf("Resolve(%q): by title %v with %d matches, want %v with %d", tt.ref, ambiguous.ByTitle, len(ambiguous.Matches), tt.byTitle, tt.wantCount)
+			}
+			continue
+		}
+		if err != nil || note.ID != tt.wantID {
+			t.Errorf("Resolve(%q) = %v, %v, want %s", tt.ref, note, err, tt.wantID)
+		}
+	}
+
+	if _, err := c.Resolve(ctx, ""); err == nil {
+		t.Errorf("Resolve of an empty reference succeeded")
+	}
+}
@@ -0,0 +1,228 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// PinnedTag marks notes that are always part of the primer.
+const PinnedTag = "pinned"
+
+// maxSectionNotes bounds the notes read per primer section, so a tag on
+// thousands of notes doesn't slow down session start.
+const maxSectionNotes = 50
+
+// Primer sections.
+const (
+	SectionPinned = "pinned"
+	SectionRepo   = "repo"
+	SectionRecent = "recent"
+)
+
+// PrimerOptions configures Primer.
+type PrimerOptions struct {
+	// Repo is the name of the current repository; notes tagged with it are
+	// included after the pinned notes
+	Repo string
+	// Budget is the maximum estimated token count of the primer
+	Budget int
+	// Recent is the number of recently updated notes listed by title
+	Recent int
+	// Reveal includes the content of sensitive notes, recording each
+	// reveal in the audit log; otherwise it is masked (see Protect)
+	Reveal bool
+	// Guard is the prompt-injection guard applied to the notes included in
+	// full (see GuardInjection)
+	Guard injection.Mode
+}
+
+// PrimerNote describes a note included in a primer.
+type PrimerNote struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Section  string `json:"section"`
+	// Full is true if the content was included, not just the title
+	Full bool `json:"full"`
+	// Injection names the prompt-injection patterns found by the guard
+	Injection []string `json:"injection,omitempty"`
+}
+
+// PrimerResult is a compact memory primer for the start of an agent session.
+type PrimerResult struct {
+	Repo   string       `json:"repo,omitempty"`
+	Text   string       `json:"text"`
+	Notes  []PrimerNote `json:"notes"`
+	Tokens int          `json:"tokens"`
+	Budget int          `json:"budget"`
+}
+
+// Tagged returns up to limit notes carrying tag, most recently updated
+// first. A limit of zero or less returns all of them.
+func (c *Client) Tagged(ctx context.Context, tag string, limit int) ([]*models.Note, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	return c.store.Tagged(tag, limit)
+}
+
+// Recent returns up to limit notes, most recently updated first.
+func (c *Client) Recent(ctx context.Context, limit int) ([]*models.Note, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	return c.store.Recent(limit)
+}
+
+// Primer builds the memory primer injected at the start of an agent
+// session: pinned notes and notes tagged with the repo name in full, then
+// the titles of recently updated notes. Each note appears once, in the first
+// section it qualifies for. Notes fall back to a title line when their
+// content doesn't fit the budget, and the primer stops at the first line
+// that doesn't fit. Only indexed lookups of a bounded number of notes are
+// used, so the cost doesn't grow with the size of the store.
+func (c *Client) Primer(ctx context.Context, opts PrimerOptions) (*PrimerResult, error) {
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("budget must be positive")
+	}
+
+	pinned, err := c.Tagged(ctx, PinnedTag, maxSectionNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	var repo []*models.Note
+	if opts.Repo != "" && !strings.EqualFold(opts.Repo, PinnedTag) {
+		if repo, err = c.Tagged(ctx, opts.Repo, maxSectionNotes); err != nil {
+			return nil, err
+		}
+	}
+
+	var recent []*models.Note
+	if opts.Recent > 0 {
+		if recent, err = c.Recent(ctx, opts.Recent+len(pinned)+len(repo)); err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.Reveal {
+		if err := c.Protect(append(pinned, repo...), false, ""); err != nil {
+			return nil, err
+		}
+	}
+	GuardInjection(append(pinned, repo...), opts.Guard)
+
+	result := &PrimerResult{Repo: opts.Repo, Notes: []PrimerNote{}, Budget: opts.Budget}
+	p := primer{remaining: opts.Budget, seen: make(map[string]bool), result: result}
+
+	p.add("# Memory\n\nFrom braindump. Run `braindump recall \"<task>\"` for notes relevant to a specific task.\n\n")
+
+	p.section(SectionPinned, "## Pinned\n\n", pinned, true, 0)
+	p.section(SectionRepo, fmt.Sprintf("## Tagged %s\n\n", opts.Repo), repo, true, 0)
+	p.section(SectionRecent, "## Recently updated\n\n", recent, false, opts.Recent)
+
+	if opts.Reveal {
+		if err := c.Protect(p.shown, true, "context"); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Text = p.text.String()
+	result.Tokens = EstimateTokens(result.Text)
+	return result, nil
+}
+
+// primer accumulates the text of a primer within its token budget. Every
+// part ends in a newline so the estimate of the text is the sum of its
+// parts.
+type primer struct {
+	text      strings.Builder
+	remaining int
+	full      bool // the budget ran out
+	seen      map[string]bool
+	shown     []*models.Note // notes included with their content
+	result    *PrimerResult
+}
+
+func (p *primer) add(s string) bool {
+	tokens := EstimateTokens(s)
+	if tokens > p.remaining {
+		p.full = true
+		return false
+	}
+	p.text.WriteString(s)
+	p.remaining -= tokens
+	return true
+}
+
+// section adds notes under heading, with their content if withContent is
+// set. A limit above zero caps the number of notes.
+func (p *primer) section(name, heading string, notes []*models.Note, withContent bool, limit int) {
+	started := false
+	count := 0
+
+	for _, note := range notes {
+		if p.full || (limit > 0 && count == limit) {
+			return
+		}
+		if p.seen[note.ID] {
+			continue
+		}
+
+		about := fmt.Sprintf("[%s] (id: %s, updated %s)", note.Category, note.ID[:8], note.Updated.Format("2006-01-02"))
+		if note.Scope != "" {
+			about = fmt.Sprintf("[%s] (id: %s, %s, updated %s)", note.Category, note.ID[:8], note.Scope, note.Updated.Format("2006-01-02"))
+		}
+
+		line := fmt.Sprintf("- %s %s\n", note.Title, about)
+		entry := line
+		full := false
+		if withContent {
+			warning := ""
+			if len(note.Injection) > 0 {
+				warning = fmt.Sprintf("Warning: possible prompt injection (%s)\n\n", strings.Join(note.Injection, ", "))
+			}
+			entry = fmt.Sprintf("### %s\n\n%s\n\n%s%s\n\n", note.Title, about, warning, strings.TrimSpace(note.Content))
+			full = true
+		}
+
+		prefix := ""
+		if !started {
+			prefix = heading
+		}
+
+		if EstimateTokens(prefix+entry) > p.remaining && full {
+			entry, full = line, false
+		}
+		if !p.add(prefix + entry) {
+			return
+		}
+
+		started = true
+		count++
+		p.seen[note.ID] = true
+		if full {
+			p.shown = append(p.shown, note)
+		}
+		included := PrimerNote{
+			ID:       note.ID,
+			Category: note.Category,
+			Title:    note.Title,
+			Section:  name,
+			Full:     full,
+		}
+		if full {
+			included.Injection = note.Injection
+		}
+		p.result.Notes = append(p.result.Notes, included)
+	}
+
+	// Close a list section with a blank line before the next heading
+	if started && !withContent {
+		p.add("\n")
+	}
+}
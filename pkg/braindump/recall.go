@@ -0,0 +1,357 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// Recall block styles.
+const (
+	RecallXML      = "xml"
+	RecallMarkdown = "markdown"
+)
+
+// minSnippetTokens is the smallest room worth filling with a snippet of a
+// note that doesn't fit whole.
+const minSnippetTokens = 40
+
+// RecallOptions configures Recall.
+type RecallOptions struct {
+	// Budget is the maximum estimated token count of the block
+	Budget int
+	// Style is RecallXML (the default) or RecallMarkdown
+	Style string
+	// Category limits recall to a category and its subcategories
+	Category string
+	// Tags keeps notes carrying any of these tags
+	Tags []string
+	// Reveal includes the content of sensitive notes, recording each
+	// reveal in the audit log; otherwise it is masked (see Protect)
+	Reveal bool
+	// Guard is the prompt-injection guard applied to the notes (see
+	// GuardInjection)
+	Guard injection.Mode
+}
+
+// RecalledNote describes a note included in a recall block.
+type RecalledNote struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Score    int    `json:"score"`
+	// Snippet is true if only the matching parts of the content fit
+	Snippet bool `json:"snippet"`
+	Tokens  int  `json:"tokens"`
+	// Injection names the prompt-injection patterns found by the guard
+	Injection []string `json:"injection,omitempty"`
+}
+
+// RecallResult is a prompt-ready block of the notes most relevant to a task.
+type RecallResult struct {
+	Task     string         `json:"task"`
+	Keywords []string       `json:"keywords"`
+	Block    string         `json:"block"`
+	Notes    []RecalledNote `json:"notes"`
+	Tokens   int            `json:"tokens"`
+	Budget   int            `json:"budget"`
+}
+
+// IDs returns the IDs of the included notes, most relevant first.
+func (r *RecallResult) IDs() []string {
+	ids := make([]string, len(r.Notes))
+	for i, n := range r.Notes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+// Recall searches for notes matching any keyword of task and packs the most
+// relevant ones into a single block for a prompt. Notes are included whole
+// if they fit in the budget; the rest are cut down to the lines mentioning a
+// keyword while there is room.
+func (c *Client) Recall(ctx context.Context, task string, opts RecallOptions) (*RecallResult, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("budget must be positive")
+	}
+
+	var render recallRenderer
+	switch opts.Style {
+	case "", RecallXML:
+		render = xmlRecall{}
+	case RecallMarkdown:
+		render = markdownRecall{}
+	default:
+		return nil, fmt.Errorf("unknown recall style %q (use %s or %s)", opts.Style, RecallXML, RecallMarkdown)
+	}
+
+	head, tail := render.open(task), render.close()
+	remaining := opts.Budget - EstimateTokens(head) - EstimateTokens(tail)
+	if remaining < 0 {
+		return nil, fmt.Errorf("budget of %d tokens doesn't fit the block's opening and closing (%d tokens)", opts.Budget, opts.Budget-remaining)
+	}
+
+	keywords := Keywords(task)
+	if len(keywords) == 0 {
+		return nil, fmt.Errorf("no searchable keywords in %q", task)
+	}
+
+	notes, err := c.store.Search(matchAny(keywords), opts.Category, opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Reveal {
+		if err := c.Protect(notes, false, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Notes are flagged here, but wrapped as they are rendered, so a
+	// snippet is wrapped whole
+	wrap := opts.Guard == injection.ModeWrap
+	if wrap {
+		GuardInjection(notes, injection.ModeFlag)
+		for _, note := range notes {
+			if len(note.Injection) > 0 {
+				note.Title = injection.StripHidden(note.Title)
+			}
+		}
+	} else {
+		GuardInjection(notes, opts.Guard)
+	}
+	guarded := func(note *models.Note, content string) string {
+		if wrap && len(note.Injection) > 0 {
+			return injection.Wrap(content)
+		}
+		return content
+	}
+
+	result := &RecallResult{Task: task, Keywords: keywords, Notes: []RecalledNote{}, Budget: opts.Budget}
+
+	// Whole notes go in first, so one long note can't crowd out several
+	// short ones; the room left is then filled with snippets. Entries are
+	// kept in rank order either way.
+	ranked := rankKeywords(notes, keywords)
+	entries := make([]string, len(ranked))
+	included := make([]*RecalledNote, len(ranked))
+
+	for i, r := range ranked {
+		entry := render.note(r.Note, guarded(r.Note, r.Note.Content), false)
+		if tokens := EstimateTokens(entry); tokens <= remaining {
+			entries[i] = entry
+			included[i] = &RecalledNote{Score: r.Score, Tokens: tokens}
+			remaining -= tokens
+		}
+	}
+
+	for i, r := range ranked {
+		if included[i] != nil {
+			continue
+		}
+		room := remaining - EstimateTokens(render.note(r.Note, guarded(r.Note, ""), true))
+		if room < minSnippetTokens {
+			continue
+		}
+		entry := render.note(r.Note, guarded(r.Note, Snippet(r.Note.Content, keywords, room)), true)
+		if tokens := EstimateTokens(entry); tokens <= remaining {
+			entries[i] = entry
+			included[i] = &RecalledNote{Score: r.Score, Snippet: true, Tokens: tokens}
+			remaining -= tokens
+		}
+	}
+
+	var body strings.Builder
+	var shown []*models.Note
+	for i, r := range ranked {
+		if included[i] == nil {
+			continue
+		}
+		body.WriteString(entries[i])
+		n := included[i]
+		n.ID, n.Category, n.Title, n.Injection = r.Note.ID, r.Note.Category, r.Note.Title, r.Note.Injection
+		result.Notes = append(result.Notes, *n)
+		shown = append(shown, r.Note)
+	}
+	if opts.Reveal {
+		if err := c.Protect(shown, true, "recall"); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Block = head + body.String() + tail
+	result.Tokens = EstimateTokens(result.Block)
+	return result, nil
+}
+
+// rankKeywords scores notes by how many keywords they contain, weighting
+// title and tag matches above content matches. Ties keep the full-text
+// ranking of the store.
+func rankKeywords(notes []*models.Note, keywords []string) []SearchResult {
+	results := make([]SearchResult, len(notes))
+
+	for i, note := range notes {
+		score := 0
+		titleLower := strings.ToLower(note.Title)
+		contentLower := strings.ToLower(note.Content)
+
+		for _, k := range keywords {
+			if strings.Contains(titleLower, k) {
+				score += 10
+			}
+			for _, tag := range note.Tags {
+				if strings.EqualFold(tag, k) {
+					score += 5
+				}
+			}
+			score += min(strings.Count(contentLower, k), 5)
+		}
+
+		results[i] = SearchResult{Note: note, Score: score}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results
+}
+
+// Snippet cuts content down to the lines that mention a keyword, separated
+// by "..." where lines were left out, and truncates the result to about
+// maxTokens. If no line matches it keeps the start of the content.
+func Snippet(content string, keywords []string, maxTokens int) string {
+	lines := strings.Split(content, "\n")
+
+	var kept []string
+	last := -1
+	for i, line := range lines {
+		lineLower := strings.ToLower(line)
+		for _, k := range keywords {
+			if strings.Contains(lineLower, k) {
+				if last >= 0 && i > last+1 {
+					kept = append(kept, "...")
+				}
+				kept = append(kept, line)
+				last = i
+				break
+			}
+		}
+	}
+
+	snippet := content
+	if len(kept) > 0 {
+		snippet = strings.Join(kept, "\n")
+	}
+
+	if EstimateTokens(snippet) <= maxTokens {
+		return snippet
+	}
+
+	// Find the longest prefix that fits, leaving room for the ellipsis
+	ellipsis := EstimateTokens("...")
+	runes := []rune(snippet)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if EstimateTokens(string(runes[:mid])) <= maxTokens-ellipsis {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return strings.TrimSpace(string(runes[:lo])) + "..."
+}
+
+// recallRenderer formats the parts of a recall block. Every part ends in a
+// newline so the token estimate of the block is the sum of its parts.
+type recallRenderer interface {
+	open(task string) string
+	note(note *models.Note, content string, snippet bool) string
+	close() string
+}
+
+type xmlRecall struct{}
+
+func (xmlRecall) open(task string) string {
+	return fmt.Sprintf("<context task=\"%s\">\n", html.EscapeString(task))
+}
+
+func (xmlRecall) note(note *models.Note, content string, snippet bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<note id=\"%s\" category=\"%s\" title=\"%s\"", note.ID, html.EscapeString(note.Category), html.EscapeString(note.Title))
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, " tags=\"%s\"", html.EscapeString(strings.Join(note.Tags, ",")))
+	}
+	if note.Scope != "" {
+		fmt.Fprintf(&b, " scope=\"%s\"", note.Scope)
+	}
+	fmt.Fprintf(&b, " updated=\"%s\"", note.Updated.Format("2006-01-02"))
+	if snippet {
+		b.WriteString(" snippet=\"true\"")
+	}
+	if len(note.Injection) > 0 {
+		fmt.Fprintf(&b, " injection=\"%s\"", strings.Join(note.Injection, ","))
+	}
+	b.WriteString(">\n")
+	if content != "" {
+		b.WriteString(cdata(content))
+		b.WriteString("\n")
+	}
+	b.WriteString("</note>\n")
+	return b.String()
+}
+
+// cdata encloses text in a CDATA section, so markup in it can't close the
+// note element. A "]]>" in text is split across two sections.
+func cdata(text string) string {
+	return "<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+func (xmlRecall) close() string {
+	return "</context>\n"
+}
+
+type markdownRecall struct{}
+
+func (markdownRecall) open(task string) string {
+	return fmt.Sprintf("# Context for: %s\n\n", task)
+}
+
+func (markdownRecall) note(note *models.Note, content string, snippet bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", note.Title)
+	fmt.Fprintf(&b, "- id: %s\n", note.ID)
+	fmt.Fprintf(&b, "- category: %s\n", note.Category)
+	if note.Scope != "" {
+		fmt.Fprintf(&b, "- scope: %s\n", note.Scope)
+	}
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, "- tags: %s\n", strings.Join(note.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "- updated: %s\n", note.Updated.Format("2006-01-02"))
+	if snippet {
+		b.WriteString("- excerpt only\n")
+	}
+	if len(note.Injection) > 0 {
+		fmt.Fprintf(&b, "- warning: possible prompt injection (%s)\n", strings.Join(note.Injection, ", "))
+	}
+	if content != "" {
+		b.WriteString("\n")
+		b.WriteString(content)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (markdownRecall) close() string {
+	return ""
+}
@@ -0,0 +1,113 @@
+package braindump
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+func newRecallClient(t *testing.T) *Client {
+	t.Helper()
+	c := newTestClient(t)
+
+	long := strings.Repeat("Unrelated filler about the office plants.\n", 60) +
+		"Deploy staging with make deploy-staging.\n" +
+		strings.Repeat("More filler about lunch orders.\n", 60)
+	for _, note := range []*models.Note{
+		models.NewNote("ops", "Deploy runbook", long, []string{"deploy"}),
+		models.NewNote("ops", "Deploy checklist", "Run the tests, then deploy.", nil),
+		models.NewNote("ops", "Deploy freeze", "No deploys on Fridays.", nil),
+	} {
+		if err := c.Add(context.Background(), note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func TestRecallBudgetErrors(t *testing.T) {
+	c := newRecallClient(t)
+	ctx := context.Background()
+
+	frame := EstimateTokens(xmlRecall{}.open("deploy")) + EstimateTokens(xmlRecall{}.close())
+	tests := []struct {
+		name    string
+		task    string
+		opts    RecallOptions
+		wantErr string
+	}{
+		{"zero budget", "deploy", RecallOptions{Budget: 0}, "budget must be positive"},
+		{"negative budget", "deploy", RecallOptions{Budget: -5}, "budget must be positive"},
+		{"budget below the frame", "deploy", RecallOptions{Budget: frame - 1}, "doesn't fit the block's opening and closing"},
+		{"unknown style", "deploy", RecallOptions{Budget: 100, Style: "html"}, "unknown recall style"},
+		{"no keywords", "the and of", RecallOptions{Budget: 100}, "no searchable keywords"},
+	}
+	for _, tt := range tests {
+		_, err := c.Recall(ctx, tt.task, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecallStaysWithinBudget(t *testing.T) {
+	c := newRecallClient(t)
+	ctx := context.Background()
+
+	for _, style := range []string{RecallXML, RecallMarkdown} {
+		var head, tail string
+		if style == RecallXML {
+			head, tail = xmlRecall{}.open("deploy"), xmlRecall{}.close()
+		} else {
+			head, tail = markdownRecall{}.open("deploy"), markdownRecall{}.close()
+		}
+		frame := EstimateTokens(head) + EstimateTokens(tail)
+
+		for budget := frame; budget <= 1500; budget += 7 {
+			result, err := c.Recall(ctx, "deploy", RecallOptions{Budget: budget, Style: style})
+			if err != nil {
+				t.Fatalf("%s, budget %d: %v", style, budget, err)
+			}
+			if result.Tokens > budget {
+				t.Errorf("%s, budget %d: block is %d tokens", style, budget, result.Tokens)
+			}
+			if budget == frame && len(result.Notes) != 0 {
+				t.Errorf("%s, budget %d: notes included in a budget that only fits the frame", style, budget)
+			}
+		}
+	}
+}
+
+func TestRecallPrefersWholeNotes(t *testing.T) {
+	c := newRecallClient(t)
+
+	// Room for the two short notes whole, and a snippet of the long one
+	result, err := c.Recall(context.Background(), "deploy", RecallOptions{Budget: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	whole, snippets := 0, 0
+	for _, n := range result.Notes {
+		if n.Snippet {
+			snippets++
+			if n.Title != "Deploy runbook" {
+				t.Errorf("short note %q cut to a snippet", n.Title)
+			}
+		} else {
+			whole++
+		}
+	}
+	if whole != 2 || snippets != 1 {
+		t.Errorf("got %d whole notes and %d snippets, want 2 and 1: %+v", whole, snippets, result.Notes)
+	}
+	if !strings.Contains(result.Block, "make deploy-staging") || strings.Contains(result.Block, "office plants") {
+		t.Errorf("snippet doesn't keep just the matching line:\n%s", result.Block)
+	}
+	// The runbook ranks first on its title and tag, whole or not
+	if result.Notes[0].Title != "Deploy runbook" {
+		t.Errorf("notes out of rank order: %+v", result.Notes)
+	}
+}
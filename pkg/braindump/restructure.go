@@ -0,0 +1,234 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// Merge folds the content and tags of sources into target. Each source is
+// appended under a "## <title>" heading and then deleted; their IDs are
+// recorded in the target's merged_from metadata. The target is encrypted if
+// any source is, so merged content stays encrypted at rest. It returns the
+// updated target and the number of notes merged.
+func (c *Client) Merge(ctx context.Context, target string, sources []string) (*models.Note, int, error) {
+	into, err := c.Resolve(ctx, target)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var notes []*models.Note
+	seen := map[string]bool{into.ID: true}
+	for _, idOrTitle := range sources {
+		note, err := c.Resolve(ctx, idOrTitle)
+		if err != nil {
+			return nil, 0, err
+		}
+		if seen[note.ID] {
+			continue
+		}
+		seen[note.ID] = true
+		notes = append(notes, note)
+	}
+
+	if len(notes) == 0 {
+		return nil, 0, fmt.Errorf("nothing to merge: all notes resolve to the target")
+	}
+
+	// Sources are deleted after the target is written, so check that every
+	// change is allowed before making any
+	if guarded, ok := c.store.(storage.Guarded); ok {
+		for _, note := range append([]*models.Note{into}, notes...) {
+			if err := guarded.CheckWrite(note.ID, into.Category); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	mergeNotes(into, notes)
+
+	// The target is written first, with the same checks as any update, so
+	// a failure leaves every note as it was
+	if err := c.Update(ctx, into); err != nil {
+		return nil, 0, err
+	}
+
+	for _, note := range notes {
+		if err := c.store.Delete(note.ID); err != nil {
+			return nil, 0, fmt.Errorf("merged into %s, but failed to delete merged note %s: %w", into.ID[:8], note.ID[:8], err)
+		}
+	}
+
+	return into, len(notes), nil
+}
+
+func mergeNotes(target *models.Note, sources []*models.Note) {
+	var ids []string
+	if prev := target.Metadata["merged_from"]; prev != "" {
+		ids = strings.Split(prev, ",")
+	}
+
+	if target.Metadata == nil {
+		target.Metadata = make(map[string]string)
+	}
+
+	content := target.Content
+	for _, note := range sources {
+		content += fmt.Sprintf("\n\n## %s\n\n%s", note.Title, note.Content)
+		target.Tags = mergeTags(target.Tags, note.Tags)
+		if note.Created.Before(target.Created) {
+			target.Created = note.Created
+		}
+		if note.Metadata[storage.EncryptedKey] == "true" {
+			target.Metadata[storage.EncryptedKey] = "true"
+		}
+		ids = append(ids, note.ID)
+	}
+
+	target.Metadata["merged_from"] = strings.Join(ids, ",")
+	target.Content = strings.TrimSpace(content)
+	target.Updated = time.Now()
+}
+
+func mergeTags(tags []string, extra []string) []string {
+	for _, tag := range extra {
+		if !hasAnyTag(tags, []string{tag}) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Split breaks a note into one note per "## " heading. Each section becomes
+// a new note in the same category, titled after its heading, carrying the
+// original tags and split_from metadata and encrypted if the original is.
+// Text before the first heading stays in the original note; if there is
+// none, the original takes over the first section. It returns the original
+// note followed by the new ones.
+func (c *Client) Split(ctx context.Context, idOrTitle string) ([]*models.Note, error) {
+	note, err := c.Resolve(ctx, idOrTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	preamble, sections := splitSections(note.Content)
+	if len(sections) == 0 || (len(sections) == 1 && preamble == "") {
+		return nil, fmt.Errorf("nothing to split: note has no additional \"## \" headings")
+	}
+
+	// Parts are titled after their headings, numbered where a title is
+	// taken in the category, since the title names the note's file
+	siblings, err := c.List(ctx, ListFilter{Category: note.Category, NoRecurse: true})
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool)
+	for _, n := range siblings {
+		if n.ID != note.ID {
+			taken[storage.NoteFile(n)] = true
+		}
+	}
+	// The original's file stays until it is rewritten, after the parts
+	oldFile := storage.NoteFile(note)
+	unique := func(n *models.Note) {
+		title := n.Title
+		for i := 2; taken[storage.NoteFile(n)]; i++ {
+			n.Title = fmt.Sprintf("%s (%d)", title, i)
+		}
+		taken[storage.NoteFile(n)] = true
+	}
+
+	if preamble == "" {
+		note.Title = sections[0].title
+		note.Content = sections[0].content
+		sections = sections[1:]
+		unique(note)
+	} else {
+		note.Content = preamble
+	}
+	taken[oldFile] = true
+	taken[storage.NoteFile(note)] = true
+	note.Updated = time.Now()
+
+	var parts []*models.Note
+	for _, sec := range sections {
+		part := models.NewNote(note.Category, sec.title, sec.content, append([]string(nil), note.Tags...))
+		part.Created = note.Created
+		part.Metadata["split_from"] = note.ID
+		if note.Metadata[storage.EncryptedKey] == "true" {
+			part.Metadata[storage.EncryptedKey] = "true"
+		}
+		unique(part)
+		if err := validate(part); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	// The parts are added before the original is cut down, and removed again
+	// if anything fails, so no content is lost
+	var added []*models.Note
+	undo := func(err error) ([]*models.Note, error) {
+		for _, part := range added {
+			c.store.Delete(part.ID)
+		}
+		return nil, err
+	}
+	for _, part := range parts {
+		if err := c.Add(ctx, part); err != nil {
+			return undo(err)
+		}
+		added = append(added, part)
+	}
+	if err := c.Update(ctx, note); err != nil {
+		return undo(err)
+	}
+
+	return append([]*models.Note{note}, parts...), nil
+}
+
+type section struct {
+	title   string
+	content string
+}
+
+// splitSections breaks markdown content on "## " headings, ignoring headings
+// inside fenced code blocks. It returns the text before the first heading and
+// the sections that follow.
+func splitSections(content string) (string, []section) {
+	var preamble strings.Builder
+	var sections []section
+	var current *strings.Builder
+	inFence := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+
+		if !inFence && strings.HasPrefix(line, "## ") {
+			if current != nil {
+				sections[len(sections)-1].content = strings.TrimSpace(current.String())
+			}
+			sections = append(sections, section{title: strings.TrimSpace(line[3:])})
+			current = &strings.Builder{}
+			continue
+		}
+
+		if current != nil {
+			current.WriteString(line + "\n")
+		} else {
+			preamble.WriteString(line + "\n")
+		}
+	}
+
+	if current != nil {
+		sections[len(sections)-1].content = strings.TrimSpace(current.String())
+	}
+
+	return strings.TrimSpace(preamble.String()), sections
+}
@@ -0,0 +1,193 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/MohGanji/braindump/pkg/injection"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// RulesOptions select the notes rendered into a rules file section.
+type RulesOptions struct {
+	// Section names the section, so a file can hold several
+	Section string
+	// Category limits the notes to a category and its subcategories
+	Category string
+	// Tags limits the notes to those carrying any of these tags
+	Tags []string
+	// Budget is the maximum estimated token count of the section
+	Budget int
+	// SensitiveTags mark notes to leave out, in addition to SensitiveTag
+	// and encrypted notes
+	SensitiveTags []string
+	// Guard is the prompt-injection guard applied to the notes included in
+	// full (see GuardInjection)
+	Guard injection.Mode
+}
+
+// RulesNote describes a note rendered into a rules section.
+type RulesNote struct {
+	ID       string `json:"id" yaml:"id"`
+	Category string `json:"category" yaml:"category"`
+	Title    string `json:"title" yaml:"title"`
+	// Full is true if the content was included, not just the title
+	Full bool `json:"full" yaml:"full"`
+	// Injection names the prompt-injection patterns found by the guard
+	Injection []string `json:"injection,omitempty" yaml:"injection,omitempty"`
+}
+
+// RulesResult is a section of an agent rules file such as AGENTS.md or
+// CLAUDE.md.
+type RulesResult struct {
+	Section string      `json:"section" yaml:"section"`
+	Text    string      `json:"text" yaml:"text"`
+	Notes   []RulesNote `json:"notes" yaml:"notes"`
+	// Omitted counts the notes that didn't fit the budget at all
+	Omitted int `json:"omitted" yaml:"omitted"`
+	// Withheld counts the sensitive notes left out
+	Withheld int `json:"withheld" yaml:"withheld"`
+	Tokens   int `json:"tokens" yaml:"tokens"`
+	Budget   int `json:"budget" yaml:"budget"`
+}
+
+// ValidateSection checks that name can be used in a section's markers:
+// letters, digits, "-", "_" and ".".
+func ValidateSection(name string) error {
+	if name == "" {
+		return fmt.Errorf("section name must not be empty")
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' {
+			return fmt.Errorf("section name %q may only contain letters, digits, \"-\", \"_\" and \".\"", name)
+		}
+	}
+	return nil
+}
+
+// markerPrefix starts the comments marking sections.
+const markerPrefix = "<!-- braindump:"
+
+// sectionMarkers returns the comments that open and close a section.
+func sectionMarkers(section string) (begin, end string) {
+	return markerPrefix + "begin " + section + " -->", markerPrefix + "end " + section + " -->"
+}
+
+// escapeMarkers keeps note text from opening or closing a section: the
+// "<" of a marker is escaped, so it shows as text rather than a comment.
+func escapeMarkers(text string) string {
+	return strings.ReplaceAll(text, markerPrefix, "&lt;"+markerPrefix[1:])
+}
+
+// Rules renders the selected notes into a section for an agent rules file,
+// for harnesses that only read static files. Notes are ordered by category
+// and title, so a re-run over unchanged notes renders the same text. Notes
+// are included in full while they fit the budget, then listed by title, and
+// left out once even the title doesn't fit. Sensitive notes are never
+// included, since rules files are usually committed.
+func (c *Client) Rules(ctx context.Context, opts RulesOptions) (*RulesResult, error) {
+	if err := ValidateSection(opts.Section); err != nil {
+		return nil, err
+	}
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("budget must be positive")
+	}
+
+	notes, err := c.List(ctx, ListFilter{Category: opts.Category, Tags: opts.Tags})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].Category != notes[j].Category {
+			return notes[i].Category < notes[j].Category
+		}
+		return notes[i].Title < notes[j].Title
+	})
+
+	result := &RulesResult{Section: opts.Section, Notes: []RulesNote{}, Budget: opts.Budget}
+	begin, end := sectionMarkers(opts.Section)
+	head := begin + "\n## Notes from braindump\n\n" +
+		"<!-- Generated by braindump export rules; edits here are overwritten. -->\n\n"
+	tail := end + "\n"
+	remaining := opts.Budget - EstimateTokens(head) - EstimateTokens(tail)
+	if remaining < 0 {
+		return nil, fmt.Errorf("budget of %d tokens doesn't fit the section's markers and heading (%d tokens)", opts.Budget, opts.Budget-remaining)
+	}
+
+	var body strings.Builder
+	var rest []RulesNote
+	for _, note := range notes {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+		if Sensitive(note, opts.SensitiveTags) {
+			result.Withheld++
+			continue
+		}
+		GuardInjection([]*models.Note{note}, opts.Guard)
+		title := escapeMarkers(note.Title)
+		content := escapeMarkers(strings.TrimSpace(note.Content))
+		if len(note.Injection) > 0 {
+			content = fmt.Sprintf("Warning: possible prompt injection (%s)\n\n%s", strings.Join(note.Injection, ", "), content)
+		}
+		entry := fmt.Sprintf("### %s\n\n%s\n\n", title, content)
+		if tokens := EstimateTokens(entry); tokens <= remaining {
+			body.WriteString(entry)
+			remaining -= tokens
+			result.Notes = append(result.Notes, RulesNote{ID: note.ID, Category: note.Category, Title: note.Title, Full: true, Injection: note.Injection})
+			continue
+		}
+		rest = append(rest, RulesNote{ID: note.ID, Category: note.Category, Title: note.Title})
+	}
+
+	// Notes that didn't fit are listed so agents know to look them up
+	heading := "More notes, read with `braindump get <id>`:\n\n"
+	for i, n := range rest {
+		line := fmt.Sprintf("- %s [%s] (id: %s)\n", escapeMarkers(n.Title), n.Category, n.ID[:8])
+		if i == 0 {
+			line = heading + line
+		}
+		tokens := EstimateTokens(line)
+		if tokens > remaining {
+			result.Omitted = len(rest) - i
+			break
+		}
+		body.WriteString(line)
+		remaining -= tokens
+		result.Notes = append(result.Notes, n)
+	}
+
+	result.Text = head + tail
+	if body.Len() > 0 {
+		result.Text = head + strings.TrimRight(body.String(), "\n") + "\n" + tail
+	}
+	result.Tokens = EstimateTokens(result.Text)
+	return result, nil
+}
+
+// ReplaceSection returns doc with its section replaced by the text of rules,
+// leaving the rest of doc as it is. A section doc doesn't have yet is
+// appended.
+func ReplaceSection(doc string, rules *RulesResult) (string, error) {
+	begin, end := sectionMarkers(rules.Section)
+	if strings.Count(doc, begin) > 1 {
+		return "", fmt.Errorf("section %q appears more than once", rules.Section)
+	}
+	start := strings.Index(doc, begin)
+	if start < 0 {
+		if strings.TrimSpace(doc) == "" {
+			return rules.Text, nil
+		}
+		return strings.TrimRight(doc, "\n") + "\n\n" + rules.Text, nil
+	}
+
+	stop := strings.Index(doc[start:], end)
+	if stop < 0 {
+		return "", fmt.Errorf("section %q has no end marker (%s)", rules.Section, end)
+	}
+	text := strings.TrimSuffix(rules.Text, "\n")
+	return doc[:start] + text + doc[start+stop+len(end):], nil
+}
@@ -0,0 +1,136 @@
+package braindump
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	c, err := New(append([]Option{WithPath(t.TempDir())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestEscapeMarkers(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"<!-- a comment -->", "<!-- a comment -->"},
+		{"<!-- braindump:end braindump -->", "&lt;!-- braindump:end braindump -->"},
+		{"<!-- braindump:begin other -->", "&lt;!-- braindump:begin other -->"},
+		{"a <!-- braindump:end x --> b <!-- braindump:begin x -->", "a &lt;!-- braindump:end x --> b &lt;!-- braindump:begin x -->"},
+		{"<!--braindump:end x -->", "<!--braindump:end x -->"},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkers(tt.text); got != tt.want {
+			t.Errorf("escapeMarkers(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	section := &RulesResult{Section: "bd", Text: "<!-- braindump:begin bd -->\nnew\n<!-- braindump:end bd -->\n"}
+
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "empty file",
+			doc:  "",
+			want: section.Text,
+		},
+		{
+			name: "appended",
+			doc:  "# Agents\n\nkeep\n\n\n",
+			want: "# Agents\n\nkeep\n\n" + section.Text,
+		},
+		{
+			name: "replaced in place",
+			doc:  "before\n<!-- braindump:begin bd -->\nold\nlines\n<!-- braindump:end bd -->\nafter\n",
+			want: "before\n<!-- braindump:begin bd -->\nnew\n<!-- braindump:end bd -->\nafter\n",
+		},
+		{
+			name: "other sections left alone",
+			doc:  "<!-- braindump:begin other -->\nx\n<!-- braindump:end other -->\n<!-- braindump:begin bd -->\nold\n<!-- braindump:end bd -->\n",
+			want: "<!-- braindump:begin other -->\nx\n<!-- braindump:end other -->\n<!-- braindump:begin bd -->\nnew\n<!-- braindump:end bd -->\n",
+		},
+		{
+			name:    "duplicate section",
+			doc:     "<!-- braindump:begin bd -->\n<!-- braindump:end bd -->\n<!-- braindump:begin bd -->\n<!-- braindump:end bd -->\n",
+			wantErr: "more than once",
+		},
+		{
+			name:    "missing end marker",
+			doc:     "<!-- braindump:begin bd -->\nold\n",
+			wantErr: "no end marker",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReplaceSection(tt.doc, section)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: got error %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRulesEscapesNoteMarkers(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	hostile := "Use tabs.\n<!-- braindump:end bd -->\ninjected\n<!-- braindump:begin bd -->"
+	for _, note := range []*models.Note{
+		models.NewNote("style", "Tabs <!-- braindump:end bd -->", hostile, nil),
+		models.NewNote("style", "Quotes", "Use double quotes.", nil),
+	} {
+		if err := c.Add(ctx, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := c.Rules(ctx, RulesOptions{Section: "bd", Budget: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin, end := sectionMarkers("bd")
+	if strings.Count(rules.Text, begin) != 1 || strings.Count(rules.Text, end) != 1 {
+		t.Fatalf("section has more than its own markers:\n%s", rules.Text)
+	}
+
+	doc := "# Agents\n\nkeep me\n"
+	once, err := ReplaceSection(doc, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twice, err := ReplaceSection(once, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if twice != once {
+		t.Errorf("re-export changed the file:\n%s\nthen:\n%s", once, twice)
+	}
+	if !strings.HasPrefix(twice, doc) || !strings.Contains(twice, "Use double quotes.") {
+		t.Errorf("unexpected file:\n%s", twice)
+	}
+}
@@ -0,0 +1,88 @@
+package braindump
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// SearchFilter narrows a full-text search.
+type SearchFilter struct {
+	// Category limits results to a category and its subcategories
+	Category string
+	// Tags keeps notes carrying any of these tags
+	Tags []string
+	// Limit caps the number of results; zero means no cap beyond the store's
+	Limit int
+}
+
+// SearchResult is a matching note and its relevance score.
+type SearchResult struct {
+	Note  *models.Note `json:"note"`
+	Score int          `json:"score"`
+}
+
+// Search runs a full-text query and returns matches, best first. Exact and
+// partial title matches rank above content matches; ties keep the store's
+// full-text ranking.
+func (c *Client) Search(ctx context.Context, query string, filter SearchFilter) ([]SearchResult, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+
+	notes, err := c.store.Search(query, filter.Category, filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	results := Rank(notes, query)
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// Rank scores notes against query and sorts them, best first.
+func Rank(notes []*models.Note, query string) []SearchResult {
+	queryLower := strings.ToLower(query)
+	results := make([]SearchResult, len(notes))
+
+	for i, note := range notes {
+		score := 0
+		titleLower := strings.ToLower(note.Title)
+		contentLower := strings.ToLower(note.Content)
+
+		if titleLower == queryLower {
+			score += 100
+		} else if strings.Contains(titleLower, queryLower) {
+			score += 50
+		}
+
+		if strings.Contains(contentLower, queryLower) {
+			if strings.HasPrefix(contentLower, queryLower) {
+				score += 30
+			} else {
+				score += 10
+			}
+		}
+
+		results[i] = SearchResult{Note: note, Score: score}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results
+}
+
+// Notes returns the notes of results, in order.
+func Notes(results []SearchResult) []*models.Note {
+	notes := make([]*models.Note, len(results))
+	for i, r := range results {
+		notes[i] = r.Note
+	}
+	return notes
+}
@@ -0,0 +1,123 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/secrets"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// secretGuard applies a secrets policy to notes before they are written.
+type secretGuard struct {
+	policy secrets.Policy
+	notify func(note *models.Note, findings []secrets.Finding)
+}
+
+// WithSecretPolicy scans the content of notes being added or updated for
+// secrets and applies policy: PolicyBlock fails with secrets.ErrSecret,
+// PolicyRedact replaces the secrets and PolicyEncrypt stores the note
+// encrypted. notify, if not nil, is called with the findings of every note
+// that is still written. Notes already encrypted are not scanned.
+func WithSecretPolicy(policy secrets.Policy, notify func(note *models.Note, findings []secrets.Finding)) Option {
+	return func(o *options) {
+		o.secrets = secretGuard{policy: policy, notify: notify}
+	}
+}
+
+func (g secretGuard) check(note *models.Note) error {
+	if g.policy == "" || g.policy == secrets.PolicyOff || note.Metadata[storage.EncryptedKey] == "true" {
+		return nil
+	}
+
+	findings := secrets.Scan(note.Content)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	switch g.policy {
+	case secrets.PolicyBlock:
+		return fmt.Errorf("%w in %q: %s", secrets.ErrSecret, note.Title, secrets.Summary(findings))
+	case secrets.PolicyRedact:
+		note.Content = secrets.Redact(note.Content, findings)
+	case secrets.PolicyEncrypt:
+		if note.Metadata == nil {
+			note.Metadata = make(map[string]string)
+		}
+		note.Metadata[storage.EncryptedKey] = "true"
+	}
+
+	if g.notify != nil {
+		g.notify(note, findings)
+	}
+	return nil
+}
+
+// NoteFindings are the secrets or injection patterns found in one note.
+type NoteFindings struct {
+	ID       string    `json:"id" yaml:"id"`
+	Category string    `json:"category" yaml:"category"`
+	Title    string    `json:"title" yaml:"title"`
+	Scope    string    `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Findings []Finding `json:"findings" yaml:"findings"`
+}
+
+// Finding is something found in a note by a scan.
+type Finding struct {
+	// Rule names the pattern that matched
+	Rule string `json:"rule" yaml:"rule"`
+	// Line is the 1-based line the match starts on, or 0 for a match in
+	// the title
+	Line int `json:"line" yaml:"line"`
+	// Preview shows the match, masked for secrets
+	Preview string `json:"preview" yaml:"preview"`
+}
+
+// ScanSecrets returns the secrets stored in plaintext in the notes of
+// category and its subcategories, or of every note if category is empty.
+// Encrypted notes are skipped.
+func (c *Client) ScanSecrets(ctx context.Context, category string) ([]NoteFindings, error) {
+	notes, err := c.List(ctx, ListFilter{Category: category})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []NoteFindings{}
+	for _, note := range notes {
+		if note.Metadata[storage.EncryptedKey] == "true" {
+			continue
+		}
+		findings := secrets.Scan(note.Content)
+		if len(findings) == 0 {
+			continue
+		}
+		report := newNoteFindings(note)
+		for _, f := range findings {
+			report.Findings = append(report.Findings, Finding{Rule: f.Rule, Line: f.Line, Preview: f.Preview})
+		}
+		reports = append(reports, report)
+	}
+
+	sortFindings(reports)
+	return reports, nil
+}
+
+func newNoteFindings(note *models.Note) NoteFindings {
+	return NoteFindings{
+		ID:       note.ID,
+		Category: note.Category,
+		Title:    note.Title,
+		Scope:    note.Scope,
+	}
+}
+
+func sortFindings(reports []NoteFindings) {
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Category != reports[j].Category {
+			return reports[i].Category < reports[j].Category
+		}
+		return reports[i].Title < reports[j].Title
+	})
+}
@@ -0,0 +1,65 @@
+package braindump
+
+import (
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// SensitiveTag marks notes whose content is hidden in output unless it is
+// explicitly revealed.
+const SensitiveTag = "sensitive"
+
+// MaskedContent replaces the content of sensitive notes hidden by Protect.
+const MaskedContent = "[sensitive content hidden]"
+
+// WithSensitiveTags marks notes carrying any of tags as sensitive, in
+// addition to SensitiveTag and encrypted notes.
+func WithSensitiveTags(tags []string) Option {
+	return func(o *options) {
+		o.sensitiveTags = tags
+	}
+}
+
+// Sensitive reports whether note's content should be hidden by default: it
+// is tagged SensitiveTag or one of tags, or it is encrypted at rest.
+func Sensitive(note *models.Note, tags []string) bool {
+	if note.Metadata[storage.EncryptedKey] == "true" {
+		return true
+	}
+	for _, tag := range note.Tags {
+		if strings.EqualFold(tag, SensitiveTag) {
+			return true
+		}
+		for _, t := range tags {
+			if strings.EqualFold(tag, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Protect prepares notes to be shown, replacing the content of sensitive
+// ones with MaskedContent. If reveal is set the content is kept instead, and
+// each reveal is first recorded in the audit log of the store holding the
+// note, so nothing is shown if it can't be recorded. command names what
+// showed the notes in the log.
+func (c *Client) Protect(notes []*models.Note, reveal bool, command string) error {
+	var revealed []*models.Note
+	for _, note := range notes {
+		if !Sensitive(note, c.sensitiveTags) {
+			continue
+		}
+		if !reveal {
+			note.Content = MaskedContent
+			continue
+		}
+		revealed = append(revealed, note)
+	}
+	if r, ok := c.store.(storage.Revealer); ok && len(revealed) > 0 {
+		return r.RecordReveals(revealed, command)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package braindump
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// Categories returns every category that holds notes, with the number of
+// notes filed directly in it.
+func (c *Client) Categories(ctx context.Context) (map[string]int, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	return c.store.CategoryCounts()
+}
+
+// RenameCategory moves a category and its subcategories to a new name that
+// is not in use yet.
+func (c *Client) RenameCategory(ctx context.Context, oldName, newName string) error {
+	if err := check(ctx); err != nil {
+		return err
+	}
+
+	categories, err := c.store.GetCategories()
+	if err != nil {
+		return err
+	}
+	clean := storage.CleanCategory(newName)
+	for _, cat := range categories {
+		if storage.IsInCategory(cat, clean) {
+			return fmt.Errorf("%w: category already exists: %s (merge the categories instead)", storage.ErrConflict, clean)
+		}
+	}
+
+	return c.store.MoveCategory(oldName, newName)
+}
+
+// MergeCategory moves every note of src and its subcategories into dst.
+func (c *Client) MergeCategory(ctx context.Context, src, dst string) error {
+	if err := check(ctx); err != nil {
+		return err
+	}
+	return c.store.MoveCategory(src, dst)
+}
+
+// Tags returns every tag with the number of notes carrying it.
+func (c *Client) Tags(ctx context.Context) (map[string]int, error) {
+	if err := check(ctx); err != nil {
+		return nil, err
+	}
+	return c.store.TagCounts()
+}
+
+// RenameTag replaces a tag on every note, merging it into newTag if that is
+// already in use. It returns the number of notes changed.
+func (c *Client) RenameTag(ctx context.Context, oldTag, newTag string) (int, error) {
+	if err := check(ctx); err != nil {
+		return 0, err
+	}
+	return c.store.RenameTag(oldTag, newTag)
+}
+
+// DeleteTag removes a tag from every note. It returns the number of notes
+// changed.
+func (c *Client) DeleteTag(ctx context.Context, tag string) (int, error) {
+	if err := check(ctx); err != nil {
+		return 0, err
+	}
+	return c.store.DeleteTag(tag)
+}
+
+// NormalizeTags re-applies the store's tag policy to every note. It returns
+// the number of notes changed.
+func (c *Client) NormalizeTags(ctx context.Context) (int, error) {
+	if err := check(ctx); err != nil {
+		return 0, err
+	}
+	return c.store.NormalizeTags()
+}
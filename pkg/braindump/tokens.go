@@ -0,0 +1,87 @@
+package braindump
+
+import (
+	"strings"
+	"unicode"
+)
+
+// EstimateTokens approximates how many LLM tokens s takes. Words count one
+// token per four letters or digits, rounded up, and every punctuation mark
+// and CJK character counts as a token of its own. This overestimates
+// English prose slightly, which keeps packed context on the safe side of a
+// budget.
+func EstimateTokens(s string) int {
+	tokens := 0
+	wordLen := 0
+	flush := func() {
+		tokens += (wordLen + 3) / 4
+		wordLen = 0
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLen++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// stopwords are left out of keyword queries because they match nearly
+// every note.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a about above after again against all also am an and any are as at be
+		because been before being below between both but by can could did do
+		does doing done down during each few for from further get got had has
+		have having he her here hers him his how i if in into is it its itself
+		just let like make me more most my need no nor not now of off on once
+		only or other our ours out over own please same she should so some
+		such than that the their theirs them then there these they this those
+		through to too under until up use used using very want was we were
+		what when where which while who whom why will with would you your
+		yours`) {
+		stopwords[w] = true
+	}
+}
+
+// Keywords extracts the distinct searchable words of text, lowercased, in
+// order of first appearance. Stopwords and single characters are dropped.
+func Keywords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, w := range words {
+		if len([]rune(w)) < 2 || stopwords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		keywords = append(keywords, w)
+	}
+	return keywords
+}
+
+// matchAny builds a full-text query matching notes that contain any of the
+// keywords. Each keyword is quoted so FTS5 operators in it have no effect.
+func matchAny(keywords []string) string {
+	quoted := make([]string, len(keywords))
+	for i, k := range keywords {
+		quoted[i] = `"` + strings.ReplaceAll(k, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " OR ")
+}
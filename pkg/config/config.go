@@ -0,0 +1,350 @@
+// Package config reads and writes the braindump configuration file.
+//
+// The file holds default settings and named profiles that override them:
+//
+//	profile: work
+//	format: text
+//	profiles:
+//	  work:
+//	    store: ~/notes/work
+//	    category: inbox
+//	  client-acme:
+//	    store: ~/notes/acme
+//	    format: json
+//	    tags:
+//	      aliases:
+//	        acme-corp: acme
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are what the config file, a profile or the environment can set.
+type Settings struct {
+	// Store is the notes directory; a leading ~ is the home directory
+	Store string `yaml:"store,omitempty"`
+	// Format is the default output format
+	Format string `yaml:"format,omitempty"`
+	// Category is used by add when no category is given
+	Category string `yaml:"category,omitempty"`
+	// Tags are normalization rules added to those of each store's tags.yaml
+	Tags *storage.TagPolicy `yaml:"tags,omitempty"`
+	// KeyFile holds the passphrase encrypted notes are sealed with
+	KeyFile string `yaml:"key_file,omitempty"`
+	// Secrets is the policy for secrets found in notes being written: off,
+	// warn, block, redact or encrypt
+	Secrets string `yaml:"secrets,omitempty"`
+	// SensitiveTags mark notes whose content is hidden unless revealed, in
+	// addition to the sensitive tag
+	SensitiveTags []string `yaml:"sensitive_tags,omitempty"`
+	// Injection guards notes that are printed against prompt injection: off,
+	// flag or wrap
+	Injection string `yaml:"injection,omitempty"`
+	// ReadOnly makes every change fail
+	ReadOnly bool `yaml:"read_only,omitempty"`
+}
+
+// Config is the contents of the config file.
+type Config struct {
+	// Profile is selected when no profile is given on the command line or
+	// in BRAINDUMP_PROFILE
+	Profile  string `yaml:"profile,omitempty"`
+	Settings `yaml:",inline"`
+	Profiles map[string]Settings `yaml:"profiles,omitempty"`
+}
+
+// Path returns the config file location: $BRAINDUMP_CONFIG if set, else
+// braindump/config.yaml under $XDG_CONFIG_HOME or ~/.config.
+func Path() string {
+	if path := os.Getenv("BRAINDUMP_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".config", "braindump", "config.yaml")
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "braindump", "config.yaml")
+}
+
+// Load reads the config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to path, creating its directory if needed.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the settings of profile layered over the defaults. An
+// empty profile selects the config's default profile, if any.
+func (c *Config) Resolve(profile string) (Settings, error) {
+	if profile == "" {
+		profile = c.Profile
+	}
+
+	settings := c.Settings
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return Settings{}, fmt.Errorf("unknown profile %q", profile)
+		}
+		settings = Merge(settings, p)
+	}
+
+	settings.Store = expandHome(settings.Store)
+	settings.KeyFile = expandHome(settings.KeyFile)
+	return settings, nil
+}
+
+// Env returns the settings given by BRAINDUMP_STORE, BRAINDUMP_FORMAT,
+// BRAINDUMP_CATEGORY, BRAINDUMP_KEY_FILE, BRAINDUMP_SECRETS,
+// BRAINDUMP_INJECTION and BRAINDUMP_READ_ONLY.
+func Env() Settings {
+	return Settings{
+		Store:     expandHome(os.Getenv("BRAINDUMP_STORE")),
+		Format:    os.Getenv("BRAINDUMP_FORMAT"),
+		Category:  os.Getenv("BRAINDUMP_CATEGORY"),
+		KeyFile:   expandHome(os.Getenv("BRAINDUMP_KEY_FILE")),
+		Secrets:   os.Getenv("BRAINDUMP_SECRETS"),
+		Injection: os.Getenv("BRAINDUMP_INJECTION"),
+		ReadOnly:  envBool("BRAINDUMP_READ_ONLY"),
+	}
+}
+
+// Passphrase returns the encryption passphrase: $BRAINDUMP_KEY if set, else
+// the contents of the key file, which defaults to key next to the config
+// file. It is empty if neither exists.
+func (s Settings) Passphrase() (string, error) {
+	if key := os.Getenv("BRAINDUMP_KEY"); key != "" {
+		return key, nil
+	}
+
+	path := s.KeyFile
+	if path == "" {
+		path = filepath.Join(filepath.Dir(Path()), "key")
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && s.KeyFile == "" {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Merge returns base with the settings made in over replacing its own. Tag
+// aliases are combined, with those of over winning, and so are sensitive
+// tags.
+func Merge(base, over Settings) Settings {
+	if over.Store != "" {
+		base.Store = over.Store
+	}
+	if over.Format != "" {
+		base.Format = over.Format
+	}
+	if over.Category != "" {
+		base.Category = over.Category
+	}
+	if over.KeyFile != "" {
+		base.KeyFile = over.KeyFile
+	}
+	if over.Secrets != "" {
+		base.Secrets = over.Secrets
+	}
+	if over.Injection != "" {
+		base.Injection = over.Injection
+	}
+	if over.ReadOnly {
+		base.ReadOnly = true
+	}
+	if len(over.SensitiveTags) > 0 {
+		base.SensitiveTags = append(append([]string{}, base.SensitiveTags...), over.SensitiveTags...)
+	}
+	if over.Tags != nil {
+		tags := *over.Tags
+		if base.Tags != nil {
+			tags = tags.WithDefaults(*base.Tags)
+		}
+		base.Tags = &tags
+	}
+	return base
+}
+
+// Get returns the value at a dotted key such as format or
+// profiles.work.store. Sections are returned as YAML.
+func (c *Config) Get(key string) (string, error) {
+	m, err := c.toMap()
+	if err != nil {
+		return "", err
+	}
+
+	var value interface{} = m
+	for _, part := range strings.Split(key, ".") {
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("config key not set: %s", key)
+		}
+		if value, ok = section[part]; !ok {
+			return "", fmt.Errorf("config key not set: %s", key)
+		}
+	}
+
+	if _, ok := value.(map[string]interface{}); ok {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// Set sets the value at a dotted key, parsing it as YAML so booleans and
+// sections can be given. An empty value removes the key.
+func (c *Config) Set(key, value string) error {
+	m, err := c.toMap()
+	if err != nil {
+		return err
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	parts := strings.Split(key, ".")
+	section := m
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := section[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			section[part] = next
+		}
+		section = next
+	}
+
+	last := parts[len(parts)-1]
+	if parsed == nil {
+		delete(section, last)
+	} else {
+		section[last] = parsed
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	cfg, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("invalid config key or value %s: %w", key, err)
+	}
+	*c = *cfg
+	return nil
+}
+
+// List returns every set key with its value, sorted by key.
+func (c *Config) List() ([][2]string, error) {
+	m, err := c.toMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries [][2]string
+	var walk func(prefix string, section map[string]interface{})
+	walk = func(prefix string, section map[string]interface{}) {
+		for k, v := range section {
+			if sub, ok := v.(map[string]interface{}); ok {
+				walk(prefix+k+".", sub)
+				continue
+			}
+			entries = append(entries, [2]string{prefix + k, fmt.Sprint(v)})
+		}
+	}
+	walk("", m)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i][0] < entries[j][0] })
+	return entries, nil
+}
+
+func (c *Config) toMap() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decode parses a config, rejecting unknown keys.
+func decode(data []byte) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// envBool reports whether the environment variable is set to a true value
+// such as 1, true or yes.
+func envBool(name string) bool {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
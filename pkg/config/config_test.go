@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+func TestMerge(t *testing.T) {
+	base := Settings{
+		Store:         "/notes",
+		Format:        "text",
+		Secrets:       "warn",
+		SensitiveTags: []string{"private"},
+		Tags:          &storage.TagPolicy{Aliases: map[string]string{"js": "javascript", "k8s": "kubernetes"}},
+	}
+
+	tests := []struct {
+		name string
+		over Settings
+		want Settings
+	}{
+		{
+			name: "empty override keeps base",
+			over: Settings{},
+			want: base,
+		},
+		{
+			name: "scalars replaced",
+			over: Settings{Store: "/work", Format: "json", Category: "inbox", KeyFile: "/key", Secrets: "block", Injection: "wrap"},
+			want: Settings{
+				Store: "/work", Format: "json", Category: "inbox", KeyFile: "/key", Secrets: "block", Injection: "wrap",
+				SensitiveTags: base.SensitiveTags, Tags: base.Tags,
+			},
+		},
+		{
+			name: "read-only can only be turned on",
+			over: Settings{ReadOnly: true},
+			want: Settings{Store: "/notes", Format: "text", Secrets: "warn", SensitiveTags: base.SensitiveTags, Tags: base.Tags, ReadOnly: true},
+		},
+		{
+			name: "sensitive tags and aliases combined",
+			over: Settings{
+				SensitiveTags: []string{"hr"},
+				Tags:          &storage.TagPolicy{PreserveCase: true, Aliases: map[string]string{"js": "ecmascript"}},
+			},
+			want: Settings{
+				Store: "/notes", Format: "text", Secrets: "warn",
+				SensitiveTags: []string{"private", "hr"},
+				Tags:          &storage.TagPolicy{PreserveCase: true, Aliases: map[string]string{"js": "ecmascript", "k8s": "kubernetes"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := Merge(base, tt.over); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Merging must not write through to the base's slices
+	Merge(base, Settings{SensitiveTags: []string{"hr"}})
+	if len(base.SensitiveTags) != 1 {
+		t.Errorf("Merge changed the base's sensitive tags: %v", base.SensitiveTags)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `profile: work
+format: text
+sensitive_tags: [private]
+profiles:
+  work:
+    store: ~/notes/work
+    category: inbox
+  acme:
+    store: /acme
+    format: json
+    read_only: true
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile string
+		want    Settings
+		wantErr bool
+	}{
+		{"", Settings{Store: filepath.Join(home, "notes/work"), Format: "text", Category: "inbox", SensitiveTags: []string{"private"}}, false},
+		{"acme", Settings{Store: "/acme", Format: "json", SensitiveTags: []string{"private"}, ReadOnly: true}, false},
+		{"missing", Settings{}, true},
+	}
+	for _, tt := range tests {
+		got, err := cfg.Resolve(tt.profile)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q): error %v", tt.profile, err)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Resolve(%q):\ngot  %+v\nwant %+v", tt.profile, got, tt.want)
+		}
+	}
+}
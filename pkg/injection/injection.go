@@ -0,0 +1,155 @@
+// Package injection detects prompt-injection patterns in note content.
+//
+// Notes often hold text copied from the web and flow back into prompts, so
+// content that reads like instructions to a model is worth flagging before
+// it is shown to one. Detection is heuristic: a finding means the content
+// deserves a look, not that it is malicious.
+package injection
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mode is how output is guarded against injected instructions.
+type Mode string
+
+// Modes.
+const (
+	// ModeOff leaves content as is
+	ModeOff Mode = "off"
+	// ModeFlag reports the patterns found in each note
+	ModeFlag Mode = "flag"
+	// ModeWrap also wraps content in data delimiters and strips hidden
+	// characters
+	ModeWrap Mode = "wrap"
+)
+
+// ParseMode returns the mode named s. An empty string is ModeOff.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeFlag, ModeWrap:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("unknown injection guard %q (use off, flag, wrap)", s)
+}
+
+// Finding is an instruction-like pattern found in a text.
+type Finding struct {
+	// Rule names the pattern that matched
+	Rule string
+	// Line is the 1-based line the match starts on
+	Line int
+	// Excerpt is the matched text, shortened, with hidden characters shown
+	// as code points
+	Excerpt string
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// hiddenPattern matches invisible characters: Unicode tag characters, which
+// can spell out text no one sees, zero-width characters and bidirectional
+// overrides.
+var hiddenPattern = regexp.MustCompile(`[\x{E0000}-\x{E007F}\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{2066}-\x{2069}\x{FEFF}]+`)
+
+var rules = []rule{
+	{"ignore-instructions", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+)?(?:of\s+)?(?:the\s+|your\s+)?(?:previous|prior|above|earlier|preceding|system)\s+(?:instructions?|prompts?|messages?|rules|directions|context)`)},
+	{"role-override", regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(?:a|an|in)\b|\bnew\s+(?:system\s+)?instructions\s*:|\b(?:developer|god|jailbreak|DAN)\s+mode\b`)},
+	{"chat-markup", regexp.MustCompile(`(?im)<\|im_(?:start|end)\|>|\[/?INST\]|<<SYS>>|^\s*(?:system|assistant)\s*:\s*\S`)},
+	{"tool-call", regexp.MustCompile(`(?i)</?(?:function_calls|invoke|tool_call|tool_use|function_call)\b|"(?:tool_calls|function_call)"\s*:`)},
+	{"hidden-unicode", hiddenPattern},
+}
+
+// Scan returns the instruction-like patterns in text, in order of position.
+func Scan(text string) []Finding {
+	type match struct {
+		start int
+		f     Finding
+	}
+	var matches []match
+	for _, r := range rules {
+		for _, m := range r.pattern.FindAllStringIndex(text, -1) {
+			matches = append(matches, match{m[0], Finding{
+				Rule:    r.name,
+				Line:    strings.Count(text[:m[0]], "\n") + 1,
+				Excerpt: excerpt(text[m[0]:m[1]]),
+			}})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	findings := make([]Finding, len(matches))
+	for i, m := range matches {
+		findings[i] = m.f
+	}
+	return findings
+}
+
+// Rules returns the names of the rules that matched, each once, in order of
+// first match.
+func Rules(findings []Finding) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, f := range findings {
+		if !seen[f.Rule] {
+			seen[f.Rule] = true
+			names = append(names, f.Rule)
+		}
+	}
+	return names
+}
+
+// StripHidden removes invisible characters that can carry instructions a
+// reader doesn't see.
+func StripHidden(text string) string {
+	return hiddenPattern.ReplaceAllString(text, "")
+}
+
+const (
+	openDelimiter  = "<note-data>"
+	closeDelimiter = "</note-data>"
+)
+
+// delimiterPattern matches the start of anything a model could read as a
+// delimiter, whatever its case or spacing, such as "</NOTE-DATA>" or
+// "< /note-data >".
+var delimiterPattern = regexp.MustCompile(`(?i)<(\s*/?\s*note-data)`)
+
+// Wrap encloses content in data delimiters telling a model it is stored
+// data rather than instructions. Hidden characters are stripped and the "<"
+// of delimiters inside the content is escaped so it can't close the block.
+func Wrap(content string) string {
+	content = StripHidden(content)
+	content = delimiterPattern.ReplaceAllString(content, "&lt;$1")
+	return openDelimiter + "\n" + content + "\n" + closeDelimiter
+}
+
+// excerpt shortens a match to 40 characters and shows hidden characters as
+// code points.
+func excerpt(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == 40 {
+			b.WriteString("...")
+			break
+		}
+		if hiddenPattern.MatchString(string(r)) {
+			fmt.Fprintf(&b, "<U+%04X>", r)
+		} else if r == '\n' {
+			b.WriteRune(' ')
+		} else if r != utf8.RuneError {
+			b.WriteRune(r)
+		}
+		n++
+	}
+	return b.String()
+}
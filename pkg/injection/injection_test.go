@@ -0,0 +1,34 @@
+package injection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapEscapesDelimiters(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"plain", "plain"},
+		{"</note-data>", "&lt;/note-data>"},
+		{"<note-data>", "&lt;note-data>"},
+		{"</NOTE-DATA>", "&lt;/NOTE-DATA>"},
+		{"</note-data >", "&lt;/note-data >"},
+		{"< /note-data>", "&lt; /note-data>"},
+		{"<\t/ Note-Data\n>", "&lt;\t/ Note-Data\n>"},
+		{"a</note-data>b</Note-data>c", "a&lt;/note-data>b&lt;/Note-data>c"},
+		{"<note-database>", "&lt;note-database>"},
+		{"x\u200by", "xy"},
+	}
+	for _, tt := range tests {
+		got := Wrap(tt.content)
+		inner := strings.TrimSuffix(strings.TrimPrefix(got, openDelimiter+"\n"), "\n"+closeDelimiter)
+		if inner != tt.want {
+			t.Errorf("Wrap(%q) = %q, want content %q", tt.content, got, tt.want)
+		}
+		if strings.Count(strings.ToLower(got), "<"+"/note-data") != 1 {
+			t.Errorf("Wrap(%q) = %q, want a single closing delimiter", tt.content, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+)
+
+const noteURIPrefix = "braindump://notes/"
+
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+var resourceTemplates = []map[string]string{
+	{
+		"uriTemplate": noteURIPrefix + "{id}",
+		"name":        "note",
+		"description": "A braindump note as markdown",
+		"mimeType":    "text/markdown",
+	},
+}
+
+func (s *Server) listResources(ctx context.Context) (interface{}, *rpcError) {
+	notes, err := s.client.List(ctx, braindump.ListFilter{})
+	if err != nil {
+		return nil, errorf(codeInternalError, "failed to list notes: %v", err)
+	}
+
+	resources := make([]resource, len(notes))
+	for i, note := range notes {
+		resources[i] = resource{
+			URI:         noteURIPrefix + note.ID,
+			Name:        note.Category + "/" + note.Title,
+			Title:       note.Title,
+			Description: fmt.Sprintf("Note in %s", note.Category),
+			MimeType:    "text/markdown",
+		}
+	}
+
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	id, ok := strings.CutPrefix(p.URI, noteURIPrefix)
+	if !ok || id == "" {
+		return nil, errorf(codeInvalidParams, "unknown resource: %s", p.URI)
+	}
+
+	note, err := s.client.Get(ctx, id)
+	if err != nil {
+		// MCP reserves -32002 for resources that do not exist
+		return nil, errorf(-32002, "resource not found: %s", p.URI)
+	}
+	if err := s.protect(note); err != nil {
+		return nil, errorf(codeInternalError, "failed to read note: %v", err)
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]string{{
+			"uri":      p.URI,
+			"mimeType": "text/markdown",
+			"text":     braindump.Markdown(note),
+		}},
+	}, nil
+}
@@ -0,0 +1,198 @@
+// Package mcp serves a braindump store over the Model Context Protocol.
+//
+// Messages are JSON-RPC 2.0, one per line, as in the MCP stdio transport.
+// Notes are exposed both as tools (add, search, get, list, update, append,
+// delete) and as resources addressed by braindump://notes/<id>.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+)
+
+// ProtocolVersion is the latest MCP revision this server implements.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions lists the revisions a client may negotiate, newest first.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds a single JSON-RPC message read from the client.
+const maxMessageSize = 16 << 20
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Server answers MCP requests against a braindump client.
+type Server struct {
+	client  *braindump.Client
+	version string
+	out     *json.Encoder
+}
+
+// NewServer returns a server backed by client. version is reported to
+// clients as the server version.
+func NewServer(client *braindump.Client, version string) *Server {
+	return &Server{client: client, version: version}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func errorf(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted
+// or ctx is cancelled. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.out = json.NewEncoder(w)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		if err := s.handleMessage(ctx, line); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Server) handleMessage(ctx context.Context, line []byte) error {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return s.write(response{ID: json.RawMessage("null"), Error: errorf(codeParseError, "parse error: %v", err)})
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return s.write(response{ID: id, Error: errorf(codeInvalidRequest, "invalid request")})
+	}
+
+	result, rerr := s.dispatch(ctx, req.Method, req.Params)
+
+	// Notifications carry no ID and never get a response
+	if req.ID == nil {
+		return nil
+	}
+
+	resp := response{ID: req.ID, Result: result}
+	if rerr != nil {
+		resp.Result = nil
+		resp.Error = rerr
+	} else if result == nil {
+		resp.Result = struct{}{}
+	}
+	return s.write(resp)
+}
+
+func (s *Server) write(resp response) error {
+	resp.JSONRPC = "2.0"
+	return s.out.Encode(resp)
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, params)
+	case "resources/list":
+		return s.listResources(ctx)
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		return s.readResource(ctx, params)
+	default:
+		return nil, errorf(codeMethodNotFound, "method not found: %s", method)
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == p.ProtocolVersion {
+			version = v
+		}
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{},
+			"resources": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "braindump",
+			"version": s.version,
+		},
+		"instructions": "Persistent local memory. Search before starting a task, and store durable facts (requirements, API quirks, decisions) as notes grouped by category.",
+	}, nil
+}
+
+func decodeParams(params json.RawMessage, v interface{}) *rpcError {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return errorf(codeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// testClient drives a server over in-memory pipes, as an MCP client would
+// over stdio.
+type testClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Scanner
+	nextID int
+	done   chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	client, err := braindump.New(braindump.WithPath(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	c := &testClient{t: t, in: reqW, out: bufio.NewScanner(respR), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(client, "test").Serve(context.Background(), reqR, respW)
+		respW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() {
+		reqW.Close()
+		if err := <-c.done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return c
+}
+
+func (c *testClient) send(method string, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	if !strings.HasPrefix(method, "notifications/") {
+		c.nextID++
+		msg["id"] = c.nextID
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := c.in.Write(append(data, '\n')); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request and decodes the result of its response.
+func (c *testClient) call(method string, params interface{}, result interface{}) {
+	c.t.Helper()
+	c.send(method, params)
+	if !c.out.Scan() {
+		c.t.Fatalf("%s: no response: %v", method, c.out.Err())
+	}
+
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+		c.t.Fatalf("%s: invalid response %s: %v", method, c.out.Bytes(), err)
+	}
+	if resp.ID != c.nextID {
+		c.t.Fatalf("%s: response ID %d, want %d", method, resp.ID, c.nextID)
+	}
+	if resp.Error != nil {
+		c.t.Fatalf("%s: error %d: %s", method, resp.Error.Code, resp.Error.Message)
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		c.t.Fatalf("%s: invalid result %s: %v", method, resp.Result, err)
+	}
+}
+
+type toolResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+// callTool calls a tool and decodes the JSON in its text content into v,
+// failing the test if the tool reports an error.
+func (c *testClient) callTool(name string, args map[string]interface{}, v interface{}) {
+	c.t.Helper()
+	resp := c.callToolRaw(name, args)
+	if resp.IsError {
+		c.t.Fatalf("tool %s failed: %s", name, resp.Content[0].Text)
+	}
+	if err := json.Unmarshal([]byte(resp.Content[0].Text), v); err != nil {
+		c.t.Fatalf("tool %s: invalid result %q: %v", name, resp.Content[0].Text, err)
+	}
+}
+
+func (c *testClient) callToolRaw(name string, args map[string]interface{}) toolResponse {
+	c.t.Helper()
+	var resp toolResponse
+	c.call("tools/call", map[string]interface{}{"name": name, "arguments": args}, &resp)
+	if len(resp.Content) != 1 || resp.Content[0].Type != "text" {
+		c.t.Fatalf("tool %s: unexpected content %+v", name, resp.Content)
+	}
+	return resp
+}
+
+func TestServerEndToEnd(t *testing.T) {
+	c := newTestClient(t)
+
+	var init struct {
+		ProtocolVersion string            `json:"protocolVersion"`
+		ServerInfo      map[string]string `json:"serverInfo"`
+		Capabilities    map[string]any    `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "test", "version": "1"},
+	}, &init)
+	if init.ProtocolVersion != "2025-03-26" {
+		t.Errorf("negotiated protocol %q, want 2025-03-26", init.ProtocolVersion)
+	}
+	if init.ServerInfo["name"] != "braindump" || init.ServerInfo["version"] != "test" {
+		t.Errorf("server info %v", init.ServerInfo)
+	}
+	if _, ok := init.Capabilities["tools"]; !ok {
+		t.Errorf("tools capability missing: %v", init.Capabilities)
+	}
+	c.send("notifications/initialized", nil)
+
+	var list struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			InputSchema map[string]any `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	c.call("tools/list", nil, &list)
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("tool %s has schema %v", tool.Name, tool.InputSchema)
+		}
+	}
+	if got, want := strings.Join(names, ","), "add,search,get,list,update,append,delete"; got != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+
+	var added models.Note
+	c.callTool("add", map[string]interface{}{
+		"category": "clients/acme",
+		"title":    "Stripe webhooks",
+		"content":  "Retry failed webhooks with exponential backoff.",
+		"tags":     []string{"payments"},
+	}, &added)
+	if added.ID == "" || added.Category != "clients/acme" || added.Metadata["source"] != "mcp" {
+		t.Fatalf("added note %+v", added)
+	}
+
+	var found []models.Note
+	c.callTool("search", map[string]interface{}{"query": "webhooks"}, &found)
+	if len(found) != 1 || found[0].ID != added.ID {
+		t.Errorf("search found %+v", found)
+	}
+
+	var got models.Note
+	c.callTool("get", map[string]interface{}{"id": added.ID[:8]}, &got)
+	if got.Title != "Stripe webhooks" {
+		t.Errorf("get returned %+v", got)
+	}
+
+	var updated models.Note
+	c.callTool("append", map[string]interface{}{"id": "Stripe webhooks", "content": "Give up after 5 tries."}, &updated)
+	if !strings.HasSuffix(updated.Content, "Give up after 5 tries.") {
+		t.Errorf("append left content %q", updated.Content)
+	}
+	c.callTool("update", map[string]interface{}{"id": added.ID, "title": "Stripe webhook retries"}, &updated)
+	if updated.Title != "Stripe webhook retries" {
+		t.Errorf("update left title %q", updated.Title)
+	}
+
+	var listed []models.Note
+	c.callTool("list", map[string]interface{}{"category": "clients"}, &listed)
+	if len(listed) != 1 || listed[0].Title != "Stripe webhook retries" {
+		t.Errorf("list returned %+v", listed)
+	}
+
+	var deleted map[string]string
+	c.callTool("delete", map[string]interface{}{"id": added.ID}, &deleted)
+	if deleted["deleted"] != added.ID {
+		t.Errorf("delete returned %v", deleted)
+	}
+
+	// Tool failures are results the model can read, not protocol errors
+	if resp := c.callToolRaw("get", map[string]interface{}{"id": added.ID}); !resp.IsError {
+		t.Errorf("get of a deleted note succeeded: %s", resp.Content[0].Text)
+	}
+	if resp := c.callToolRaw("add", map[string]interface{}{"category": "../escape", "title": "x", "content": "x"}); !resp.IsError {
+		t.Errorf("add with an invalid category succeeded: %s", resp.Content[0].Text)
+	}
+}
+
+func TestServerProtocolErrors(t *testing.T) {
+	c := newTestClient(t)
+
+	for _, tt := range []struct {
+		method string
+		params interface{}
+		code   int
+	}{
+		{"no/such/method", nil, codeMethodNotFound},
+		{"tools/call", map[string]interface{}{"name": "nope"}, codeInvalidParams},
+		{"tools/call", "not an object", codeInvalidParams},
+	} {
+		c.send(tt.method, tt.params)
+		if !c.out.Scan() {
+			t.Fatalf("%s: no response", tt.method)
+		}
+		var resp response
+		if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("%s: got %s, want error %d", tt.method, c.out.Bytes(), tt.code)
+		}
+		if string(resp.ID) != fmt.Sprint(c.nextID) {
+			t.Errorf("%s: response ID %s, want %d", tt.method, resp.ID, c.nextID)
+		}
+	}
+}
+
+func TestServerMasksSensitiveNotes(t *testing.T) {
+	c := newTestClient(t)
+
+	var added models.Note
+	c.callTool("add", map[string]interface{}{
+		"category": "creds",
+		"title":    "Stripe key",
+		"content":  "sk_live_hunter2",
+		"tags":     []string{"sensitive"},
+	}, &added)
+
+	for _, call := range []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"get", map[string]interface{}{"id": added.ID}},
+		{"search", map[string]interface{}{"query": "stripe"}},
+		{"list", map[string]interface{}{}},
+		{"append", map[string]interface{}{"id": added.ID, "content": "rotated"}},
+	} {
+		resp := c.callToolRaw(call.name, call.args)
+		if resp.IsError || strings.Contains(resp.Content[0].Text, "hunter2") || !strings.Contains(resp.Content[0].Text, braindump.MaskedContent) {
+			t.Errorf("%s returned %s", call.name, resp.Content[0].Text)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+func schema(required []string, properties map[string]interface{}) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func prop(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+var tagsProp = map[string]interface{}{
+	"type":        "array",
+	"items":       map[string]string{"type": "string"},
+	"description": "tags",
+}
+
+var idProp = prop("string", "note ID, unique ID prefix, or exact title")
+
+var tools = []tool{
+	{
+		Name:        "add",
+		Description: "Store a new note in a category.",
+		InputSchema: schema([]string{"category", "title", "content"}, map[string]interface{}{
+			"category": prop("string", "category, may be nested with / (e.g. clients/acme)"),
+			"title":    prop("string", "short, searchable title"),
+			"content":  prop("string", "note content (markdown)"),
+			"tags":     tagsProp,
+		}),
+	},
+	{
+		Name:        "search",
+		Description: "Full-text search over note titles, content and tags.",
+		InputSchema: schema([]string{"query"}, map[string]interface{}{
+			"query":    prop("string", "search query"),
+			"category": prop("string", "only search this category and its subcategories"),
+			"tags":     tagsProp,
+		}),
+	},
+	{
+		Name:        "get",
+		Description: "Get a single note by ID, ID prefix or title.",
+		InputSchema: schema([]string{"id"}, map[string]interface{}{
+			"id": idProp,
+		}),
+	},
+	{
+		Name:        "list",
+		Description: "List notes, optionally limited to a category and its subcategories.",
+		InputSchema: schema(nil, map[string]interface{}{
+			"category": prop("string", "category to list"),
+		}),
+	},
+	{
+		Name:        "update",
+		Description: "Replace the title, content or tags of a note. Omitted fields are kept.",
+		InputSchema: schema([]string{"id"}, map[string]interface{}{
+			"id":      idProp,
+			"title":   prop("string", "new title"),
+			"content": prop("string", "new content"),
+			"tags":    tagsProp,
+		}),
+	},
+	{
+		Name:        "append",
+		Description: "Append content to the end of a note.",
+		InputSchema: schema([]string{"id", "content"}, map[string]interface{}{
+			"id":      idProp,
+			"content": prop("string", "content to append"),
+		}),
+	},
+	{
+		Name:        "delete",
+		Description: "Delete a note.",
+		InputSchema: schema([]string{"id"}, map[string]interface{}{
+			"id": idProp,
+		}),
+	},
+}
+
+type toolArgs struct {
+	ID       string    `json:"id"`
+	Category string    `json:"category"`
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Query    string    `json:"query"`
+	Tags     *[]string `json:"tags"`
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	var args toolArgs
+	if err := decodeParams(p.Arguments, &args); err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	var err error
+	switch p.Name {
+	case "add":
+		result, err = s.toolAdd(ctx, args)
+	case "search":
+		result, err = s.toolSearch(ctx, args)
+	case "get":
+		result, err = s.client.Resolve(ctx, args.ID)
+	case "list":
+		result, err = s.toolList(ctx, args)
+	case "update":
+		result, err = s.toolUpdate(ctx, args)
+	case "append":
+		result, err = s.toolAppend(ctx, args)
+	case "delete":
+		result, err = s.toolDelete(ctx, args)
+	default:
+		return nil, errorf(codeInvalidParams, "unknown tool: %s", p.Name)
+	}
+	if err == nil {
+		err = s.protect(result)
+	}
+
+	// Tool failures are reported in the result so the model can see them
+	if err != nil {
+		return toolResult(describeError(err), true), nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, errorf(codeInternalError, "failed to encode result: %v", err)
+	}
+	return toolResult(string(data), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func (s *Server) toolAdd(ctx context.Context, args toolArgs) (interface{}, error) {
+	if args.Category == "" || args.Title == "" || args.Content == "" {
+		return nil, fmt.Errorf("category, title and content are required")
+	}
+
+	var tags []string
+	if args.Tags != nil {
+		tags = *args.Tags
+	}
+
+	note := models.NewNote(args.Category, args.Title, args.Content, tags)
+	note.Metadata["source"] = "mcp"
+	if err := s.client.Add(ctx, note); err != nil {
+		return nil, fmt.Errorf("failed to add note: %w", err)
+	}
+	return note, nil
+}
+
+func (s *Server) toolSearch(ctx context.Context, args toolArgs) (interface{}, error) {
+	if args.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	filter := braindump.SearchFilter{Category: args.Category}
+	if args.Tags != nil {
+		filter.Tags = *args.Tags
+	}
+
+	results, err := s.client.Search(ctx, args.Query, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	return nonNil(braindump.Notes(results)), nil
+}
+
+func (s *Server) toolList(ctx context.Context, args toolArgs) (interface{}, error) {
+	notes, err := s.client.List(ctx, braindump.ListFilter{Category: args.Category})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	return nonNil(notes), nil
+}
+
+func (s *Server) toolUpdate(ctx context.Context, args toolArgs) (interface{}, error) {
+	var edit braindump.Edit
+	if args.Title != "" {
+		edit.Title = &args.Title
+	}
+	if args.Content != "" {
+		edit.Content = &args.Content
+	}
+	edit.Tags = args.Tags
+
+	if edit.Empty() {
+		return nil, fmt.Errorf("at least one of title, content or tags must be provided")
+	}
+
+	note, err := s.client.Edit(ctx, args.ID, edit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	return note, nil
+}
+
+func (s *Server) toolAppend(ctx context.Context, args toolArgs) (interface{}, error) {
+	if args.Content == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+
+	note, err := s.client.Edit(ctx, args.ID, braindump.Edit{Append: &args.Content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to append to note: %w", err)
+	}
+	return note, nil
+}
+
+func (s *Server) toolDelete(ctx context.Context, args toolArgs) (interface{}, error) {
+	note, err := s.client.Delete(ctx, args.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete note: %w", err)
+	}
+	return map[string]string{"deleted": note.ID}, nil
+}
+
+// protect masks the content of the sensitive notes in a tool result. Agents
+// can't reveal them; a person can, with the CLI.
+func (s *Server) protect(result interface{}) error {
+	switch v := result.(type) {
+	case *models.Note:
+		return s.client.Protect([]*models.Note{v}, false, "")
+	case []*models.Note:
+		return s.client.Protect(v, false, "")
+	}
+	return nil
+}
+
+// describeError spells out the candidates of an ambiguous reference so the
+// model can retry with a full ID.
+func describeError(err error) string {
+	var ambiguous *braindump.AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		return err.Error()
+	}
+
+	parts := make([]string, len(ambiguous.Matches))
+	for i, n := range ambiguous.Matches {
+		parts[i] = fmt.Sprintf("[%s] %s (id: %s)", n.Category, n.Title, n.ID)
+	}
+	return err.Error() + ": " + strings.Join(parts, ", ")
+}
+
+// nonNil makes empty results encode as [] rather than null.
+func nonNil(notes []*models.Note) []*models.Note {
+	if notes == nil {
+		return []*models.Note{}
+	}
+	return notes
+}
@@ -7,14 +7,19 @@ import (
 )
 
 type Note struct {
-	ID       string            `json:"id"`
-	Category string            `json:"category"`
-	Title    string            `json:"title"`
-	Content  string            `json:"content"`
-	Tags     []string          `json:"tags,omitempty"`
-	Created  time.Time         `json:"created"`
-	Updated  time.Time         `json:"updated"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+	ID       string            `json:"id" yaml:"id"`
+	Category string            `json:"category" yaml:"category"`
+	Title    string            `json:"title" yaml:"title"`
+	Content  string            `json:"content" yaml:"content"`
+	Tags     []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Created  time.Time         `json:"created" yaml:"created"`
+	Updated  time.Time         `json:"updated" yaml:"updated"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// Scope is set by stores layering several stores, it is not persisted
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// Injection names the prompt-injection patterns found in the content
+	// when output is guarded, it is not persisted
+	Injection []string `json:"injection,omitempty" yaml:"injection,omitempty"`
 }
 
 func NewNote(category, title, content string, tags []string) *Note {
@@ -0,0 +1,202 @@
+// Package secrets detects credentials in note content.
+//
+// Scan looks for AWS keys, Stripe keys, GitHub tokens, private keys,
+// credential assignments such as password=... and long high-entropy
+// strings. Redact replaces what it found with placeholders.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrSecret is returned when a policy blocks a note containing a secret.
+var ErrSecret = errors.New("secret detected")
+
+// Policy is what happens when a note being written contains a secret.
+type Policy string
+
+// Policies.
+const (
+	// PolicyOff skips scanning
+	PolicyOff Policy = "off"
+	// PolicyWarn stores the note and reports the findings
+	PolicyWarn Policy = "warn"
+	// PolicyBlock rejects the note with ErrSecret
+	PolicyBlock Policy = "block"
+	// PolicyRedact replaces each secret with a placeholder
+	PolicyRedact Policy = "redact"
+	// PolicyEncrypt stores the note encrypted at rest
+	PolicyEncrypt Policy = "encrypt"
+)
+
+// Policies lists the valid policies.
+var Policies = []Policy{PolicyOff, PolicyWarn, PolicyBlock, PolicyRedact, PolicyEncrypt}
+
+// ParsePolicy returns the policy named s. An empty string is PolicyWarn.
+func ParsePolicy(s string) (Policy, error) {
+	if s == "" {
+		return PolicyWarn, nil
+	}
+	for _, p := range Policies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+
+	names := make([]string, len(Policies))
+	for i, p := range Policies {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("unknown secrets policy %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// Finding is a secret found in a text.
+type Finding struct {
+	// Rule names the pattern that matched
+	Rule string `json:"rule" yaml:"rule"`
+	// Line is the 1-based line the secret starts on
+	Line int `json:"line" yaml:"line"`
+	// Preview shows the start of the secret with the rest masked
+	Preview string `json:"preview" yaml:"preview"`
+
+	start, end int
+}
+
+// rule matches a secret. If the pattern has a group, only the group is the
+// secret, so redaction keeps the surrounding key name.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var rules = []rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----|\z)`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`)},
+	{"stripe-key", regexp.MustCompile(`\b(?:sk|rk)_(?:test|live)_[0-9A-Za-z]{10,}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"credential", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?([^\s"']{8,})`)},
+}
+
+// High-entropy strings: long runs of token characters that look random.
+// Hex strings such as commit hashes and UUIDs stay below minEntropy.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{24,}`)
+
+const minEntropy = 4.2
+
+// Scan returns the secrets in text, in order of position. Overlapping
+// matches are reported once, by the first rule that found them.
+func Scan(text string) []Finding {
+	var findings []Finding
+	taken := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.end && end > f.start {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(name string, start, end int) {
+		if taken(start, end) {
+			return
+		}
+		findings = append(findings, Finding{
+			Rule:    name,
+			Line:    strings.Count(text[:start], "\n") + 1,
+			Preview: preview(text[start:end]),
+			start:   start,
+			end:     end,
+		})
+	}
+
+	for _, r := range rules {
+		for _, m := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) > 3 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			add(r.name, start, end)
+		}
+	}
+
+	for _, m := range tokenPattern.FindAllStringIndex(text, -1) {
+		if looksRandom(text[m[0]:m[1]]) {
+			add("high-entropy", m[0], m[1])
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].start < findings[j].start })
+	return findings
+}
+
+// Redact replaces each finding in text with [REDACTED:<rule>]. The findings
+// must come from Scan(text).
+func Redact(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.start])
+		b.WriteString("[REDACTED:" + f.Rule + "]")
+		last = f.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Summary describes findings in one line, e.g. "stripe-key on line 2".
+func Summary(findings []Finding) string {
+	parts := make([]string, len(findings))
+	for i, f := range findings {
+		parts[i] = fmt.Sprintf("%s on line %d", f.Rule, f.Line)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// preview keeps the first four characters of a secret and masks the rest.
+func preview(secret string) string {
+	secret = strings.SplitN(secret, "\n", 2)[0]
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", min(len(secret)-4, 8))
+}
+
+// looksRandom reports whether a token is likely a generated secret: it mixes
+// letters and digits and has high entropy.
+func looksRandom(token string) bool {
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(upper || lower) {
+		return false
+	}
+	return entropy(token) >= minEntropy
+}
+
+// entropy is the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,550 @@
+// Package server exposes a braindump store as a local HTTP/JSON API.
+//
+// Routes:
+//
+//	GET    /notes             list notes (?category=, ?tag=a,b)
+//	POST   /notes             create a note
+//	GET    /notes/{id}        get a note
+//	PUT    /notes/{id}        replace a note
+//	PATCH  /notes/{id}        change some fields of a note
+//	DELETE /notes/{id}        delete a note
+//	GET    /search?q=         full-text search (?category=, ?tag=a,b)
+//	GET    /categories        categories with note counts
+//	GET    /tags              tags with note counts
+//
+// The content of sensitive notes is masked unless a GET request sets
+// ?reveal=true; each reveal is recorded in the audit log.
+//
+// Single-note responses carry an ETag. PUT, PATCH and DELETE honor
+// If-Match and fail with 412 if the note changed in the meantime. Errors are
+// returned as {"error": {"code": "...", "message": "..."}}, with codes from
+// the Code constants.
+//
+// The API is meant for local tools. Requests must name a loopback host
+// (localhost, 127.0.0.1 or ::1) in their Host header, and POST, PUT and PATCH
+// bodies must be sent as application/json, so web pages can't reach the API
+// through DNS rebinding or cross-site form posts.
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/braindump"
+	"github.com/MohGanji/braindump/pkg/models"
+	"github.com/MohGanji/braindump/pkg/secrets"
+	"github.com/MohGanji/braindump/pkg/storage"
+)
+
+// Stable error codes returned in the "code" field of error responses
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeForbiddenHost      = "forbidden_host"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodeInvalidCategory    = "invalid_category"
+	CodeInvalidQuery       = "invalid_query"
+	CodePreconditionFailed = "precondition_failed"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeNoKey              = "no_key"
+	CodeBadKey             = "bad_key"
+	CodeSecret             = "secret"
+	CodeInternal           = "internal"
+)
+
+// maxBodySize bounds request bodies.
+const maxBodySize = 16 << 20
+
+// Server serves the HTTP API for a braindump client.
+type Server struct {
+	client *braindump.Client
+	token  string
+
+	// Store updates are read-modify-write; serialize them so concurrent
+	// requests can't interleave.
+	mu sync.RWMutex
+	// revealMu serializes the audit log entries of reveals, which are
+	// written by requests holding only the read lock.
+	revealMu sync.Mutex
+}
+
+// New returns a server for client. If token is non-empty every request must
+// send it as "Authorization: Bearer <token>".
+func New(client *braindump.Client, token string) *Server {
+	return &Server{client: client, token: token}
+}
+
+// Handler returns the HTTP handler serving the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /notes", s.handleList)
+	mux.HandleFunc("POST /notes", s.handleCreate)
+	mux.HandleFunc("GET /notes/{id}", s.handleGet)
+	mux.HandleFunc("PUT /notes/{id}", s.handleReplace)
+	mux.HandleFunc("PATCH /notes/{id}", s.handlePatch)
+	mux.HandleFunc("DELETE /notes/{id}", s.handleDelete)
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /categories", s.handleCategories)
+	mux.HandleFunc("GET /tags", s.handleTags)
+
+	return checkHost(s.authenticate(requireJSON(routeErrorsAsJSON(mux))))
+}
+
+// checkHost rejects requests whose Host header doesn't name a loopback
+// address. A page on another site can point its own domain at 127.0.0.1, but
+// the browser still sends that domain as the Host.
+func checkHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !loopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, CodeForbiddenHost, fmt.Sprintf("host %q is not a loopback address", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func loopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireJSON rejects POST, PUT and PATCH requests that aren't sent as
+// application/json. Browsers can send other types across sites without a
+// preflight.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, CodeUnsupportedMedia, "request body must be sent as Content-Type: application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="braindump"`)
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeErrorsAsJSON replaces the mux's plain-text 404 and 405 responses with
+// JSON errors.
+func routeErrorsAsJSON(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed := allowedMethods(mux, r); allowed != "" {
+			w.Header().Set("Allow", allowed)
+			writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+			return
+		}
+		writeError(w, http.StatusNotFound, CodeNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	})
+}
+
+func allowedMethods(mux *http.ServeMux, r *http.Request) string {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return strings.Join(allowed, ", ")
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	filter := braindump.ListFilter{
+		Category: r.URL.Query().Get("category"),
+		Tags:     splitTags(r.URL.Query().Get("tag")),
+	}
+
+	s.mu.RLock()
+	notes, err := s.client.List(r.Context(), filter)
+	if err == nil {
+		err = s.protect(r, notes...)
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(notes))
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "query parameter q is required")
+		return
+	}
+
+	filter := braindump.SearchFilter{
+		Category: r.URL.Query().Get("category"),
+		Tags:     splitTags(r.URL.Query().Get("tag")),
+	}
+
+	s.mu.RLock()
+	results, err := s.client.Search(r.Context(), query, filter)
+	notes := braindump.Notes(results)
+	if err == nil {
+		err = s.protect(r, notes...)
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(notes))
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	note, err := s.client.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == s.etag(note) {
+		w.Header().Set("ETag", s.etag(note))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.writeNote(w, r, http.StatusOK, note)
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var in braindump.Edit
+	if !decodeBody(w, r, &in) {
+		return
+	}
+
+	if in.Category == nil || in.Title == nil || in.Content == nil ||
+		*in.Category == "" || *in.Title == "" || *in.Content == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "category, title and content are required")
+		return
+	}
+	if err := storage.ValidateCategory(*in.Category); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var tags []string
+	if in.Tags != nil {
+		tags = *in.Tags
+	}
+	note := models.NewNote(*in.Category, *in.Title, *in.Content, tags)
+	note.Metadata["source"] = "http"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.client.Add(r.Context(), note); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/notes/"+note.ID)
+	s.writeNote(w, r, http.StatusCreated, note)
+}
+
+func (s *Server) handleReplace(w http.ResponseWriter, r *http.Request) {
+	var in braindump.Edit
+	if !decodeBody(w, r, &in) {
+		return
+	}
+
+	if in.Title == nil || in.Content == nil || *in.Title == "" || *in.Content == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "title and content are required")
+		return
+	}
+	if in.Append != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "append is only supported by PATCH")
+		return
+	}
+	if in.Tags == nil {
+		in.Tags = &[]string{}
+	}
+
+	s.modify(w, r, in)
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	var in braindump.Edit
+	if !decodeBody(w, r, &in) {
+		return
+	}
+
+	if in.Empty() {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "at least one of category, title, content, tags or append must be provided")
+		return
+	}
+
+	s.modify(w, r, in)
+}
+
+// modify applies the non-nil fields of in to the note named in the path.
+func (s *Server) modify(w http.ResponseWriter, r *http.Request, in braindump.Edit) {
+	if in.Content != nil && *in.Content == braindump.MaskedContent {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "content is the placeholder of a masked note; GET it with ?reveal=true to edit it")
+		return
+	}
+	if in.Category != nil {
+		if err := storage.ValidateCategory(*in.Category); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	if in.Title != nil && *in.Title == "" {
+		in.Title = nil
+	}
+	in.Apply(note)
+
+	if err := s.client.Update(r.Context(), note); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	s.writeNote(w, r, http.StatusOK, note)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := s.client.Delete(r.Context(), note.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookup loads the note named in the path and checks If-Match. It writes an
+// error response and returns false if the request can't proceed.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	note, err := s.client.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return nil, false
+	}
+
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != s.etag(note) {
+		w.Header().Set("ETag", s.etag(note))
+		writeError(w, http.StatusPreconditionFailed, CodePreconditionFailed, "note has changed since it was read")
+		return nil, false
+	}
+
+	return note, true
+}
+
+func (s *Server) handleCategories(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	counts, err := s.client.Categories(r.Context())
+	s.mu.RUnlock()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	type categoryCount struct {
+		Category string `json:"category"`
+		Count    int    `json:"count"`
+	}
+	result := make([]categoryCount, 0, len(counts))
+	for cat, count := range counts {
+		result = append(result, categoryCount{Category: cat, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Category < result[j].Category })
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	counts, err := s.client.Tags(r.Context())
+	s.mu.RUnlock()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	type tagCount struct {
+		Tag   string `json:"tag"`
+		Count int    `json:"count"`
+	}
+	result := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// etag identifies a version of a note. It is computed from the note as it
+// is shown masked, so it doesn't give away a hash of sensitive content; it
+// still changes with every update, since the update time does.
+func (s *Server) etag(note *models.Note) string {
+	masked := *note
+	s.client.Protect([]*models.Note{&masked}, false, "")
+	note = &masked
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s",
+		note.ID, note.Category, note.Title, note.Content,
+		strings.Join(note.Tags, "\x1f"), note.Updated.UTC().Format(time.RFC3339Nano))
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeNote writes note with its ETag, which is the same whether its
+// content is masked or revealed.
+func (s *Server) writeNote(w http.ResponseWriter, r *http.Request, status int, note *models.Note) {
+	tag := s.etag(note)
+	if err := s.protect(r, note); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("ETag", tag)
+	writeJSON(w, status, note)
+}
+
+// protect masks the content of sensitive notes, unless a GET request sets
+// ?reveal=true. Callers hold s.mu, so no change is made while reveals are
+// recorded.
+func (s *Server) protect(r *http.Request, notes ...*models.Note) error {
+	reveal, _ := strconv.ParseBool(r.URL.Query().Get("reveal"))
+	reveal = reveal && r.Method == http.MethodGet
+
+	s.revealMu.Lock()
+	defer s.revealMu.Unlock()
+	return s.client.Protect(notes, reveal, "http "+r.Method+" "+r.URL.Path)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeStoreError maps store errors to HTTP statuses and error codes.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, storage.ErrInvalidCategory):
+		writeError(w, http.StatusBadRequest, CodeInvalidCategory, err.Error())
+	case errors.Is(err, storage.ErrInvalidQuery):
+		writeError(w, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+	case errors.Is(err, storage.ErrConflict), errors.Is(err, storage.ErrAmbiguous):
+		writeError(w, http.StatusConflict, CodeConflict, err.Error())
+	case errors.Is(err, storage.ErrForbidden):
+		writeError(w, http.StatusForbidden, CodeForbidden, err.Error())
+	case errors.Is(err, storage.ErrNoKey):
+		writeError(w, http.StatusUnprocessableEntity, CodeNoKey, err.Error())
+	case errors.Is(err, storage.ErrBadKey):
+		writeError(w, http.StatusUnprocessableEntity, CodeBadKey, err.Error())
+	case errors.Is(err, secrets.ErrSecret):
+		writeError(w, http.StatusUnprocessableEntity, CodeSecret, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	type apiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	writeJSON(w, status, map[string]apiError{"error": {Code: code, Message: message}})
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// nonNil makes empty results encode as [] rather than null.
+func nonNil(notes []*models.Note) []*models.Note {
+	if notes == nil {
+		return []*models.Note{}
+	}
+	return notes
+}
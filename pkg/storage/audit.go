@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// Audited is implemented by stores that record changes in audit logs.
+type Audited interface {
+	// AuditLogs returns the logs the store writes to
+	AuditLogs() []*audit.Log
+}
+
+// Revealer is implemented by stores that record reveals of sensitive notes
+// in their audit logs.
+type Revealer interface {
+	// RecordReveals logs that the content of notes was shown by command
+	RecordReveals(notes []*models.Note, command string) error
+}
+
+// SetActor sets who changes to the store are attributed to in its audit log.
+func (s *FileStore) SetActor(actor string) {
+	s.actor = actor
+}
+
+// AuditLogs returns the store's audit log, kept in its index directory.
+func (s *FileStore) AuditLogs() []*audit.Log {
+	return []*audit.Log{s.audit}
+}
+
+func (s *FileStore) entry(action string, note *models.Note, before, after string) audit.Entry {
+	return audit.Entry{
+		Action:   action,
+		Actor:    s.actor,
+		ID:       note.ID,
+		Category: note.Category,
+		Title:    note.Title,
+		Before:   audit.Hash(before),
+		After:    audit.Hash(after),
+	}
+}
+
+// record logs a change to note. before and after are its bodies as stored,
+// empty if the note didn't exist before or doesn't after.
+func (s *FileStore) record(action string, note *models.Note, before, after string) error {
+	return s.audit.Append(s.entry(action, note, before, after))
+}
+
+// RecordReveals logs the reveals of notes in the store's audit log.
+func (s *FileStore) RecordReveals(notes []*models.Note, command string) error {
+	entries := make([]audit.Entry, len(notes))
+	for i, note := range notes {
+		entries[i] = audit.Entry{
+			Action:   audit.ActionReveal,
+			Actor:    s.actor,
+			ID:       note.ID,
+			Category: note.Category,
+			Title:    note.Title,
+			Command:  command,
+		}
+	}
+	return s.audit.Append(entries...)
+}
+
+// AuditLogs returns the logs of both stores, project first.
+func (s *LayeredStore) AuditLogs() []*audit.Log {
+	var logs []*audit.Log
+	for _, store := range []Store{s.project, s.global} {
+		if a, ok := store.(Audited); ok {
+			logs = append(logs, a.AuditLogs()...)
+		}
+	}
+	return logs
+}
+
+// RecordReveals logs each reveal in the audit log of the store holding the
+// note.
+func (s *LayeredStore) RecordReveals(notes []*models.Note, command string) error {
+	var project, global []*models.Note
+	for _, note := range notes {
+		scope := note.Scope
+		if scope == "" {
+			store, err := s.holder(note.ID)
+			if err != nil {
+				return err
+			}
+			if scope = ScopeProject; store == s.global {
+				scope = ScopeGlobal
+			}
+		}
+		if scope == ScopeGlobal {
+			global = append(global, note)
+		} else {
+			project = append(project, note)
+		}
+	}
+	return errors.Join(recordReveals(s.project, project, command), recordReveals(s.global, global, command))
+}
+
+func recordReveals(store Store, notes []*models.Note, command string) error {
+	if r, ok := store.(Revealer); ok && len(notes) > 0 {
+		return r.RecordReveals(notes, command)
+	}
+	return nil
+}
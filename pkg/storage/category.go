@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxCategorySegment bounds a single directory name, well under the 255 byte
+// limit of common filesystems.
+const maxCategorySegment = 100
+
+// reservedNames cannot be used as category segments: the index directory and
+// device names that Windows refuses to create as directories.
+var reservedNames = map[string]bool{
+	".index": true,
+
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// ValidateCategory checks that a category names a directory inside the
+// store. Categories are one or more segments separated by "/"; each segment
+// may contain letters, digits, "-", "_" and ".", must not start or end with
+// ".", and must not be a reserved name. Absolute paths and ".." are rejected.
+func ValidateCategory(category string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w %q: %s", ErrInvalidCategory, category, reason)
+	}
+
+	trimmed := strings.TrimSpace(category)
+	if trimmed == "" {
+		return invalid("must not be empty")
+	}
+	if filepath.IsAbs(trimmed) || filepath.VolumeName(trimmed) != "" ||
+		strings.HasPrefix(trimmed, "/") || strings.HasPrefix(trimmed, "\\") {
+		return invalid("must be a relative path")
+	}
+
+	for _, segment := range strings.Split(CleanCategory(category), "/") {
+		if segment == "." || segment == ".." {
+			return invalid("must not contain \".\" or \"..\"")
+		}
+		if reservedNames[strings.ToLower(segment)] {
+			return invalid(fmt.Sprintf("%q is a reserved name", segment))
+		}
+		if strings.HasPrefix(segment, ".") || strings.HasSuffix(segment, ".") {
+			return invalid("segments must not start or end with \".\"")
+		}
+		if len(segment) > maxCategorySegment {
+			return invalid(fmt.Sprintf("segments must be at most %d bytes", maxCategorySegment))
+		}
+		for _, r := range segment {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' {
+				return invalid(fmt.Sprintf("character %q is not allowed (use letters, digits, \"-\", \"_\" and \".\")", r))
+			}
+		}
+	}
+
+	return nil
+}
+
+// CleanCategory normalizes a category path: backslashes become slashes,
+// surrounding whitespace and slashes are trimmed and empty segments are
+// dropped, so "clients//acme/" becomes "clients/acme".
+func CleanCategory(category string) string {
+	category = strings.ReplaceAll(category, "\\", "/")
+
+	var segments []string
+	for _, segment := range strings.Split(category, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// ParentCategories returns the ancestors of a category, outermost first:
+// "clients/acme/api" yields ["clients", "clients/acme"].
+func ParentCategories(category string) []string {
+	var parents []string
+	for i, r := range category {
+		if r == '/' {
+			parents = append(parents, category[:i])
+		}
+	}
+	return parents
+}
+
+// IsInCategory reports whether category is parent or one of its descendants.
+func IsInCategory(category, parent string) bool {
+	return category == parent || strings.HasPrefix(category, parent+"/")
+}
+
+// categoryFilter returns an SQL condition matching a category and its
+// descendants, with its arguments.
+func categoryFilter(category string) (string, []interface{}) {
+	prefix := category + "/"
+	return `(category = ? OR substr(category, 1, ?) = ?)`, []interface{}{category, len(prefix), prefix}
+}
+
+func (s *FileStore) categoryPath(category string) string {
+	return filepath.Join(s.basePath, filepath.FromSlash(category))
+}
+
+// removeEmptyDirs removes dir and its parents, stopping at the first one that
+// is not empty or at the store root.
+func (s *FileStore) removeEmptyDirs(dir string) {
+	for dir != s.basePath && strings.HasPrefix(dir, s.basePath) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// CategoryCounts returns the number of notes filed directly in each category.
+func (s *FileStore) CategoryCounts() (map[string]int, error) {
+	rows, err := s.searchDB.Query(`SELECT category, COUNT(*) FROM notes_fts GROUP BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, err
+		}
+		counts[category] = count
+	}
+
+	return counts, rows.Err()
+}
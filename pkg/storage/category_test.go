@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+func TestValidateCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		valid    bool
+	}{
+		{"clients", true},
+		{"clients/acme", true},
+		{"clients/acme/api-v2", true},
+		{"notes_2024", true},
+		{"v1.2", true},
+		{"clients//acme/", true},
+		{`clients\acme`, true},
+		{"café", true},
+
+		{"", false},
+		{"   ", false},
+		{"..", false},
+		{"../etc", false},
+		{"clients/../../etc", false},
+		{`..\etc`, false},
+		{`clients\..\..\etc`, false},
+		{".", false},
+		{"./clients", false},
+		{"/etc", false},
+		{"/etc/passwd", false},
+		{`\etc`, false},
+		{`C:\Windows`, false},
+		{".index", false},
+		{"clients/.index", false},
+		{".hidden", false},
+		{"trailing.", false},
+		{"con", false},
+		{"clients/NUL", false},
+		{"lpt1", false},
+		{"has space", false},
+		{"semi;colon", false},
+		{"null\x00byte", false},
+		{strings.Repeat("a", maxCategorySegment+1), false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCategory(tt.category)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateCategory(%q) = %v, want nil", tt.category, err)
+		}
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("ValidateCategory(%q) = nil, want an error", tt.category)
+			} else if !errors.Is(err, ErrInvalidCategory) {
+				t.Errorf("ValidateCategory(%q) = %v, want ErrInvalidCategory", tt.category, err)
+			}
+		}
+	}
+}
+
+func TestCleanCategory(t *testing.T) {
+	tests := []struct {
+		category, want string
+	}{
+		{"clients", "clients"},
+		{"clients//acme/", "clients/acme"},
+		{"/clients/acme", "clients/acme"},
+		{`clients\acme`, "clients/acme"},
+		{" clients / acme ", "clients/acme"},
+		{"", ""},
+		{"///", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CleanCategory(tt.category); got != tt.want {
+			t.Errorf("CleanCategory(%q) = %q, want %q", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestAddRejectsHostileCategories(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "store")
+	store, err := NewFileStore(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, category := range []string{
+		"../escaped",
+		"clients/../../escaped",
+		`..\escaped`,
+		"/tmp/escaped",
+		".index",
+		".index/sub",
+		"",
+	} {
+		note := models.NewNote(category, "Hostile", "content", nil)
+		if err := store.Add(note); !errors.Is(err, ErrInvalidCategory) {
+			t.Errorf("Add in %q = %v, want ErrInvalidCategory", category, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escaped")); !os.IsNotExist(err) {
+		t.Errorf("a note was written outside the store: %v", err)
+	}
+	notes, err := store.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("List returned %d notes, want 0", len(notes))
+	}
+
+	note := models.NewNote(`clients\acme`, "Fine", "content", nil)
+	if err := store.Add(note); err != nil {
+		t.Fatalf("Add in a nested category: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "clients", "acme", "fine.md")); err != nil {
+		t.Errorf("note file not written inside the store: %v", err)
+	}
+}
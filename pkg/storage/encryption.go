@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// EncryptedKey is the metadata key set to "true" on notes whose content is
+// encrypted at rest. Once set, a note stays encrypted wherever it is moved.
+const EncryptedKey = "encrypted"
+
+const (
+	armorBegin = "-----BEGIN BRAINDUMP ENCRYPTED NOTE-----"
+	armorEnd   = "-----END BRAINDUMP ENCRYPTED NOTE-----"
+
+	// sealVersion prefixes sealed content so the scheme can change later
+	sealVersion   = 1
+	saltSize      = 16
+	kdfIterations = 600000
+)
+
+// EncryptionPolicy lists the categories whose notes are encrypted. It is
+// read from encryption.yaml in the store directory:
+//
+//	categories:
+//	  - api-creds
+//	  - clients/acme
+type EncryptionPolicy struct {
+	// Categories are encrypted along with their subcategories
+	Categories []string `yaml:"categories,omitempty"`
+}
+
+// LoadEncryptionPolicy reads an encryption policy file. A missing file
+// yields a policy that encrypts no category.
+func LoadEncryptionPolicy(path string) (EncryptionPolicy, error) {
+	var policy EncryptionPolicy
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to read encryption policy: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse encryption policy %s: %w", path, err)
+	}
+	for i, category := range policy.Categories {
+		policy.Categories[i] = CleanCategory(category)
+	}
+
+	return policy, nil
+}
+
+// Covers reports whether notes in category are encrypted.
+func (p EncryptionPolicy) Covers(category string) bool {
+	for _, c := range p.Categories {
+		if category == c || strings.HasPrefix(category, c+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// keyring derives AES-256-GCM keys from a passphrase with PBKDF2-SHA256.
+// Derivation is deliberately slow, so keys are cached by salt and new notes
+// are sealed with the store's salt, kept in the index directory. Every sealed
+// note carries its own salt, so losing the salt file only costs another
+// derivation.
+type keyring struct {
+	passphrase string
+	saltPath   string
+
+	mu    sync.Mutex
+	salt  []byte
+	aeads map[string]cipher.AEAD
+}
+
+func (k *keyring) aead(salt []byte) (cipher.AEAD, error) {
+	if aead, ok := k.aeads[string(salt)]; ok {
+		return aead, nil
+	}
+
+	key, err := pbkdf2.Key(sha256.New, k.passphrase, salt, kdfIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.aeads == nil {
+		k.aeads = make(map[string]cipher.AEAD)
+	}
+	k.aeads[string(salt)] = aead
+	return aead, nil
+}
+
+// storeSalt returns the salt new notes are sealed with, creating it on first
+// use.
+func (k *keyring) storeSalt() ([]byte, error) {
+	if k.salt != nil {
+		return k.salt, nil
+	}
+
+	if data, err := os.ReadFile(k.saltPath); err == nil && len(data) == saltSize {
+		k.salt = data
+		return k.salt, nil
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(k.saltPath, salt, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write salt: %w", err)
+	}
+	k.salt = salt
+	return k.salt, nil
+}
+
+// seal encrypts content, bound to the note ID, into an armored block.
+func (k *keyring) seal(id, content string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.passphrase == "" {
+		return "", fmt.Errorf("%w: note %s must be encrypted", ErrNoKey, id)
+	}
+	salt, err := k.storeSalt()
+	if err != nil {
+		return "", err
+	}
+	aead, err := k.aead(salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append([]byte{sealVersion}, salt...)
+	sealed = append(sealed, nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(content), []byte(id))
+
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	var b strings.Builder
+	b.WriteString(armorBegin + "\n")
+	for len(encoded) > 64 {
+		b.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString(armorEnd)
+	return b.String(), nil
+}
+
+// open decrypts an armored block sealed for the note ID.
+func (k *keyring) open(id, armored string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.passphrase == "" {
+		return "", ErrNoKey
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(armored, armorBegin), armorEnd)
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted content: %w", err)
+	}
+	if len(sealed) < 1+saltSize || sealed[0] != sealVersion {
+		return "", fmt.Errorf("unsupported encrypted content")
+	}
+
+	salt := sealed[1 : 1+saltSize]
+	aead, err := k.aead(salt)
+	if err != nil {
+		return "", err
+	}
+	rest := sealed[1+saltSize:]
+	if len(rest) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted content")
+	}
+
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to decrypt note %s, the passphrase is wrong or the content is corrupted", ErrBadKey, id)
+	}
+	return string(plain), nil
+}
+
+// isArmored reports whether content is a sealed block, as read without the
+// key.
+func isArmored(content string) bool {
+	return strings.HasPrefix(content, armorBegin) && strings.HasSuffix(content, armorEnd)
+}
+
+// isEncrypted reports whether a note's content is encrypted at rest.
+func isEncrypted(note *models.Note) bool {
+	return note.Metadata[EncryptedKey] == "true"
+}
+
+// indexedContent is the content put in the search index. Encrypted notes
+// are only searchable by title and tags.
+func indexedContent(note *models.Note) string {
+	if isEncrypted(note) {
+		return ""
+	}
+	return note.Content
+}
+
+// SetPassphrase sets the passphrase notes are encrypted and decrypted with.
+// Without one, encrypted notes are read as their armored ciphertext and
+// can't be written with new content. Reading a note the passphrase can't
+// decrypt fails with ErrBadKey.
+func (s *FileStore) SetPassphrase(passphrase string) {
+	s.keys.mu.Lock()
+	defer s.keys.mu.Unlock()
+	s.keys.passphrase = passphrase
+	s.keys.aeads = nil
+}
+
+// sealNote marks note as encrypted if it must be and returns the body to
+// write for it. Content still armored because it was read without the key
+// is written back as is.
+func (s *FileStore) sealNote(note *models.Note) (string, error) {
+	if s.encryption.Covers(note.Category) {
+		if note.Metadata == nil {
+			note.Metadata = make(map[string]string)
+		}
+		note.Metadata[EncryptedKey] = "true"
+	}
+	if !isEncrypted(note) || isArmored(note.Content) {
+		return note.Content, nil
+	}
+	return s.keys.seal(note.ID, note.Content)
+}
+
+// canWrite reports an error if note would need encrypting without a key, so
+// Update can fail before removing the old note.
+func (s *FileStore) canWrite(note *models.Note) error {
+	if (!isEncrypted(note) && !s.encryption.Covers(note.Category)) || isArmored(note.Content) {
+		return nil
+	}
+	s.keys.mu.Lock()
+	defer s.keys.mu.Unlock()
+	if s.keys.passphrase == "" {
+		return fmt.Errorf("%w: note %s must be encrypted", ErrNoKey, note.ID)
+	}
+	return nil
+}
+
+// Sealer is implemented by stores that encrypt notes, so notes leaving the
+// store, such as in an export, can stay encrypted.
+type Sealer interface {
+	// Seal marks note as encrypted if the store encrypts its category and
+	// returns its content as the store writes it: ciphertext for encrypted
+	// notes, else the content itself
+	Seal(note *models.Note) (string, error)
+	// Unseal decrypts note's content in place if it is still sealed, so it
+	// can be stored under another ID
+	Unseal(note *models.Note) error
+}
+
+func (s *FileStore) Seal(note *models.Note) (string, error) {
+	return s.sealNote(note)
+}
+
+func (s *FileStore) Unseal(note *models.Note) error {
+	if !isEncrypted(note) || !isArmored(note.Content) {
+		return nil
+	}
+	plain, err := s.keys.open(note.ID, note.Content)
+	if err != nil {
+		return err
+	}
+	note.Content = plain
+	return nil
+}
+
+// Seal seals note with the store holding it.
+func (s *LayeredStore) Seal(note *models.Note) (string, error) {
+	store, err := s.holder(note.ID)
+	if err != nil {
+		return "", err
+	}
+	if sealer, ok := store.(Sealer); ok {
+		return sealer.Seal(note)
+	}
+	return note.Content, nil
+}
+
+// Unseal decrypts note with the project store, which is given the same
+// passphrase as the global one.
+func (s *LayeredStore) Unseal(note *models.Note) error {
+	if sealer, ok := s.project.(Sealer); ok {
+		return sealer.Unseal(note)
+	}
+	return nil
+}
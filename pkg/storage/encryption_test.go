@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+func TestWrongPassphrase(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.SetPassphrase("right")
+	note := models.NewNote("creds", "Stripe", "sk_test_secret", nil)
+	note.Metadata = map[string]string{EncryptedKey: "true"}
+	if err := s.Add(note); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(note.ID)
+	if err != nil || got.Content != "sk_test_secret" {
+		t.Fatalf("right passphrase: got %v, %v", got, err)
+	}
+
+	s.SetPassphrase("wrong")
+	if _, err := s.Get(note.ID); !errors.Is(err, ErrBadKey) {
+		t.Errorf("Get with the wrong passphrase: got %v, want ErrBadKey", err)
+	}
+	if _, err := s.List(""); !errors.Is(err, ErrBadKey) {
+		t.Errorf("List with the wrong passphrase: got %v, want ErrBadKey", err)
+	}
+	if _, err := s.Search("Stripe", "", nil); !errors.Is(err, ErrBadKey) {
+		t.Errorf("Search with the wrong passphrase: got %v, want ErrBadKey", err)
+	}
+
+	// Without a key the ciphertext is read as is
+	s.SetPassphrase("")
+	got, err = s.Get(note.ID)
+	if err != nil || !isArmored(got.Content) {
+		t.Errorf("no passphrase: got %v, %v, want the ciphertext", got, err)
+	}
+}
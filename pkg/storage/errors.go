@@ -0,0 +1,26 @@
+package storage
+
+import "errors"
+
+// Errors returned by stores, wrapped with details. Test for them with
+// errors.Is.
+var (
+	// ErrNotFound means the note, category or tag does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguous means a reference matched more than one note
+	ErrAmbiguous = errors.New("ambiguous reference")
+	// ErrConflict means the change collides with existing data
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidCategory means a category name was rejected by ValidateCategory
+	ErrInvalidCategory = errors.New("invalid category")
+	// ErrNoKey means a note must be encrypted but no passphrase was set
+	ErrNoKey = errors.New("encryption key required")
+	// ErrBadKey means an encrypted note couldn't be decrypted with the
+	// passphrase set: it is the wrong one or the content was tampered with
+	ErrBadKey = errors.New("wrong encryption key")
+	// ErrForbidden means the store is read-only or the actor may not write
+	// to the category
+	ErrForbidden = errors.New("permission denied")
+	// ErrInvalidQuery means a search query isn't valid FTS5 syntax
+	ErrInvalidQuery = errors.New("invalid search query")
+)
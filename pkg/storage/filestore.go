@@ -2,31 +2,41 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/MohGanji/braindump/pkg/audit"
 	"github.com/MohGanji/braindump/pkg/models"
-	_ "modernc.org/sqlite"
 	"gopkg.in/yaml.v3"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // FileStore implements Store using markdown files + SQLite FTS5
 type FileStore struct {
-	basePath string
-	searchDB *sql.DB
+	basePath   string
+	searchDB   *sql.DB
+	tagPolicy  TagPolicy
+	encryption EncryptionPolicy
+	keys       keyring
+	audit      *audit.Log
+	actor      string
 }
 
 // Note metadata for YAML frontmatter
 type NoteMeta struct {
-	ID       string    `yaml:"id"`
-	Title    string    `yaml:"title"`
-	Created  time.Time `yaml:"created"`
-	Updated  time.Time `yaml:"updated"`
-	Tags     []string  `yaml:"tags,omitempty"`
-	Category string    `yaml:"category"`
+	ID       string            `yaml:"id"`
+	Title    string            `yaml:"title"`
+	Created  time.Time         `yaml:"created"`
+	Updated  time.Time         `yaml:"updated"`
+	Tags     []string          `yaml:"tags,omitempty"`
+	Category string            `yaml:"category"`
+	Metadata map[string]string `yaml:"metadata,omitempty"`
 }
 
 func NewFileStore(basePath string) (*FileStore, error) {
@@ -48,9 +58,25 @@ func NewFileStore(basePath string) (*FileStore, error) {
 		return nil, fmt.Errorf("failed to open search database: %w", err)
 	}
 
+	policy, err := LoadTagPolicy(filepath.Join(basePath, "tags.yaml"))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	encryption, err := LoadEncryptionPolicy(filepath.Join(basePath, "encryption.yaml"))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	store := &FileStore{
-		basePath: basePath,
-		searchDB: db,
+		basePath:   basePath,
+		searchDB:   db,
+		tagPolicy:  policy,
+		encryption: encryption,
+		keys:       keyring{saltPath: filepath.Join(indexDir, "salt")},
+		audit:      audit.Open(indexDir),
 	}
 
 	// Initialize FTS5 index
@@ -73,40 +99,317 @@ func (s *FileStore) initSearchIndex() error {
 		filepath UNINDEXED
 	);
 	`
-	_, err := s.searchDB.Exec(schema)
-	return err
+	if _, err := s.searchDB.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.migrate()
+}
+
+// migrations upgrade the index schema. Each entry runs once, in order, and
+// the number applied is tracked in the database's user_version.
+var migrations = []func(s *FileStore, tx *sql.Tx) error{
+	// 1: one row per note tag, so tags may contain spaces and can be counted
+	func(s *FileStore, tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS note_tags (
+				id TEXT NOT NULL,
+				tag TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS note_tags_id ON note_tags (id);
+			CREATE INDEX IF NOT EXISTS note_tags_tag ON note_tags (tag);
+		`); err != nil {
+			return err
+		}
+
+		notes, err := s.indexedNotes(tx)
+		if err != nil {
+			return err
+		}
+		for _, note := range notes {
+			for _, tag := range note.Tags {
+				if _, err := tx.Exec(`INSERT INTO note_tags (id, tag) VALUES (?, ?)`, note.ID, tag); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	// 2: note paths and update times in a plain table, so the most recently
+	// updated notes can be found without reading every file
+	func(s *FileStore, tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS notes_meta (
+				id TEXT PRIMARY KEY,
+				filepath TEXT NOT NULL,
+				updated INTEGER NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS notes_meta_updated ON notes_meta (updated);
+		`); err != nil {
+			return err
+		}
+
+		paths, err := indexedPaths(tx)
+		if err != nil {
+			return err
+		}
+		for _, filePath := range paths {
+			note, err := s.parseMarkdownFile(filepath.Join(s.basePath, filePath))
+			if err != nil {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO notes_meta (id, filepath, updated) VALUES (?, ?, ?)`,
+				note.ID, filePath, note.Updated.UnixNano()); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func (s *FileStore) migrate() error {
+	var version int
+	if err := s.searchDB.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.searchDB.Begin()
+		if err != nil {
+			return err
+		}
+		if err := migrations[i](s, tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate search index to version %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexedPaths returns the paths of every note in the index, relative to
+// the store.
+func indexedPaths(tx *sql.Tx) ([]string, error) {
+	rows, err := tx.Query(`SELECT filepath FROM notes_fts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			return nil, err
+		}
+		paths = append(paths, filePath)
+	}
+	return paths, rows.Err()
+}
+
+// indexedNotes reads every note referenced by the index, skipping files that
+// can no longer be parsed.
+func (s *FileStore) indexedNotes(tx *sql.Tx) ([]*models.Note, error) {
+	paths, err := indexedPaths(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*models.Note
+	for _, filePath := range paths {
+		note, err := s.parseMarkdownFile(filepath.Join(s.basePath, filePath))
+		if err != nil {
+			continue
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// indexNote adds a note to the search index, the tag table and the
+// metadata table.
+func indexNote(tx *sql.Tx, note *models.Note, relPath string) error {
+	ix, err := prepareIndexer(tx)
+	if err != nil {
+		return err
+	}
+	defer ix.close()
+	return ix.index(note, relPath)
+}
+
+// indexer adds notes to the index with statements prepared once, so many
+// notes can be indexed in one transaction without parsing SQL for each.
+type indexer struct {
+	fts, meta, tag *sql.Stmt
+}
+
+func prepareIndexer(tx *sql.Tx) (*indexer, error) {
+	ix := &indexer{}
+	var err error
+	if ix.fts, err = tx.Prepare(`
+		INSERT INTO notes_fts (id, title, content, tags, category, filepath)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`); err != nil {
+		return nil, err
+	}
+	if ix.meta, err = tx.Prepare(`INSERT OR REPLACE INTO notes_meta (id, filepath, updated) VALUES (?, ?, ?)`); err != nil {
+		ix.close()
+		return nil, err
+	}
+	if ix.tag, err = tx.Prepare(`INSERT INTO note_tags (id, tag) VALUES (?, ?)`); err != nil {
+		ix.close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+func (ix *indexer) index(note *models.Note, relPath string) error {
+	if _, err := ix.fts.Exec(note.ID, note.Title, indexedContent(note), strings.Join(note.Tags, " "), note.Category, relPath); err != nil {
+		return err
+	}
+	if _, err := ix.meta.Exec(note.ID, relPath, note.Updated.UnixNano()); err != nil {
+		return err
+	}
+	for _, tag := range note.Tags {
+		if _, err := ix.tag.Exec(note.ID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *indexer) close() {
+	for _, stmt := range []*sql.Stmt{ix.fts, ix.meta, ix.tag} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// unindexNote removes a note from the search index, the tag table and the
+// metadata table.
+func (s *FileStore) unindexNote(id string) error {
+	tx, err := s.searchDB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM notes_fts WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM note_tags WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM notes_meta WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *FileStore) Add(note *models.Note) error {
-	// Create category directory
-	categoryPath := filepath.Join(s.basePath, note.Category)
-	if err := os.MkdirAll(categoryPath, 0755); err != nil {
-		return fmt.Errorf("failed to create category directory: %w", err)
+	body, err := s.write(note, "")
+	if err != nil {
+		return err
 	}
+	return s.record(audit.ActionAdd, note, "", body)
+}
 
-	// Generate filename from title (slugify)
-	filename := slugify(note.Title) + ".md"
-	filePath := filepath.Join(categoryPath, filename)
+// write stores a note file and indexes it, returning the body as stored.
+// oldPath is the file of the note being rewritten, relative to the store,
+// or empty for a new note. The new file is written and indexed before the
+// old one is removed, so a failure leaves the store as it was. Any other
+// note's file in the way is a conflict.
+func (s *FileStore) write(note *models.Note, oldPath string) (string, error) {
+	if err := ValidateCategory(note.Category); err != nil {
+		return "", err
+	}
+	note.Tags = s.tagPolicy.Normalize(note.Tags)
+	note.Category = CleanCategory(note.Category)
+	if err := s.canWrite(note); err != nil {
+		return "", err
+	}
 
 	// Format as markdown with YAML frontmatter
-	content, err := s.formatMarkdown(note)
+	body, err := s.sealNote(note)
 	if err != nil {
-		return fmt.Errorf("failed to format markdown: %w", err)
+		return "", err
+	}
+	content, err := FormatNote(note, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to format markdown: %w", err)
+	}
+
+	// Create category directory
+	categoryPath := s.categoryPath(note.Category)
+	if err := os.MkdirAll(categoryPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create category directory: %w", err)
+	}
+
+	// Generate filename from title (slugify)
+	filePath := filepath.Join(categoryPath, slugify(note.Title)+".md")
+	relPath, _ := filepath.Rel(s.basePath, filePath)
+	inPlace := oldPath != "" && relPath == oldPath
+
+	// A note file rewritten in place is restored if indexing fails
+	previous, err := os.ReadFile(filePath)
+	if err == nil && !inPlace {
+		return "", fmt.Errorf("%w: note already exists in %s: %s", ErrConflict, note.Category, note.Title)
+	}
+	restore := func() {
+		if inPlace {
+			os.WriteFile(filePath, previous, 0644)
+		} else {
+			os.Remove(filePath)
+		}
 	}
 
 	// Write file
 	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		restore()
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Update search index
-	relPath, _ := filepath.Rel(s.basePath, filePath)
-	_, err = s.searchDB.Exec(`
-		INSERT INTO notes_fts (id, title, content, tags, category, filepath)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, note.ID, note.Title, note.Content, strings.Join(note.Tags, " "), note.Category, relPath)
+	// Update search index, replacing the old entry in the same transaction
+	tx, err := s.searchDB.Begin()
+	if err != nil {
+		restore()
+		return "", err
+	}
+	if oldPath != "" {
+		for _, table := range []string{"notes_fts", "note_tags", "notes_meta"} {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id = ?`, note.ID); err != nil {
+				tx.Rollback()
+				restore()
+				return "", err
+			}
+		}
+	}
+	if err := indexNote(tx, note, relPath); err != nil {
+		tx.Rollback()
+		restore()
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		restore()
+		return "", err
+	}
 
-	return err
+	// The old file is only removed once the index points at the new one
+	if oldPath != "" && !inPlace {
+		fullOldPath := filepath.Join(s.basePath, oldPath)
+		os.Remove(fullOldPath)
+		s.removeEmptyDirs(filepath.Dir(fullOldPath))
+	}
+	return body, nil
 }
 
 func (s *FileStore) Get(id string) (*models.Note, error) {
@@ -117,7 +420,7 @@ func (s *FileStore) Get(id string) (*models.Note, error) {
 	`, id).Scan(&filePath)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("note not found: %s", id)
+		return nil, fmt.Errorf("note %w: %s", ErrNotFound, id)
 	}
 	if err != nil {
 		return nil, err
@@ -129,9 +432,14 @@ func (s *FileStore) Get(id string) (*models.Note, error) {
 }
 
 func (s *FileStore) GetByTitle(category, title string) (*models.Note, error) {
+	if err := ValidateCategory(category); err != nil {
+		return nil, err
+	}
+	category = CleanCategory(category)
+
 	// Try exact match first
 	filename := slugify(title) + ".md"
-	exactPath := filepath.Join(s.basePath, category, filename)
+	exactPath := filepath.Join(s.categoryPath(category), filename)
 
 	if _, err := os.Stat(exactPath); err == nil {
 		return s.parseMarkdownFile(exactPath)
@@ -146,7 +454,7 @@ func (s *FileStore) GetByTitle(category, title string) (*models.Note, error) {
 	`, category, title).Scan(&filePath)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("note not found: %s/%s", category, title)
+		return nil, fmt.Errorf("note %w: %s/%s", ErrNotFound, category, title)
 	}
 	if err != nil {
 		return nil, err
@@ -156,17 +464,33 @@ func (s *FileStore) GetByTitle(category, title string) (*models.Note, error) {
 	return s.parseMarkdownFile(fullPath)
 }
 
+// List returns the notes in a category and its descendants, or every note if
+// category is empty.
 func (s *FileStore) List(category string) ([]*models.Note, error) {
 	var query string
 	var args []interface{}
 
+	category = CleanCategory(category)
 	if category != "" {
-		query = `SELECT filepath FROM notes_fts WHERE category = ? ORDER BY filepath`
-		args = []interface{}{category}
+		var filter string
+		filter, args = categoryFilter(category)
+		query = `SELECT filepath FROM notes_fts WHERE ` + filter + ` ORDER BY filepath`
 	} else {
 		query = `SELECT filepath FROM notes_fts ORDER BY filepath`
 	}
 
+	return s.queryNotes(query, args...)
+}
+
+// Recent returns up to limit notes, most recently updated first.
+func (s *FileStore) Recent(limit int) ([]*models.Note, error) {
+	return s.queryNotes(`SELECT filepath FROM notes_meta ORDER BY updated DESC, id LIMIT ?`, limit)
+}
+
+// queryNotes reads the notes at the file paths selected by query, skipping
+// files that can't be parsed. A note the passphrase can't decrypt fails the
+// query rather than going missing.
+func (s *FileStore) queryNotes(query string, args ...interface{}) ([]*models.Note, error) {
 	rows, err := s.searchDB.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -182,6 +506,9 @@ func (s *FileStore) List(category string) ([]*models.Note, error) {
 
 		fullPath := filepath.Join(s.basePath, filePath)
 		note, err := s.parseMarkdownFile(fullPath)
+		if errors.Is(err, ErrBadKey) {
+			return nil, err
+		}
 		if err != nil {
 			continue
 		}
@@ -192,26 +519,23 @@ func (s *FileStore) List(category string) ([]*models.Note, error) {
 }
 
 func (s *FileStore) Update(note *models.Note) error {
-	// Delete old entry
 	var oldPath string
-	err := s.searchDB.QueryRow(`SELECT filepath FROM notes_fts WHERE id = ?`, note.ID).Scan(&oldPath)
+	err := s.searchDB.QueryRow(`SELECT filepath FROM notes_meta WHERE id = ?`, note.ID).Scan(&oldPath)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("note %w: %s", ErrNotFound, note.ID)
+	}
 	if err != nil {
-		return fmt.Errorf("note not found: %s", note.ID)
+		return err
 	}
+	before, _ := s.storedBody(oldPath)
 
-	fullOldPath := filepath.Join(s.basePath, oldPath)
-
-	// Delete from index
-	_, err = s.searchDB.Exec(`DELETE FROM notes_fts WHERE id = ?`, note.ID)
+	// Write the new file (handles category and title changes) before the old
+	// one is removed
+	after, err := s.write(note, oldPath)
 	if err != nil {
 		return err
 	}
-
-	// Delete old file
-	os.Remove(fullOldPath)
-
-	// Add as new (handles category changes)
-	return s.Add(note)
+	return s.record(audit.ActionUpdate, note, before, after)
 }
 
 func (s *FileStore) Delete(id string) error {
@@ -219,21 +543,184 @@ func (s *FileStore) Delete(id string) error {
 	var filePath string
 	err := s.searchDB.QueryRow(`SELECT filepath FROM notes_fts WHERE id = ?`, id).Scan(&filePath)
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("note not found: %s", id)
+		return fmt.Errorf("note %w: %s", ErrNotFound, id)
 	}
 	if err != nil {
 		return err
 	}
 
+	note, err := s.parseMarkdownFile(filepath.Join(s.basePath, filePath))
+	if err != nil {
+		note = &models.Note{ID: id}
+	}
+	before, _ := s.storedBody(filePath)
+
 	// Delete from index
-	_, err = s.searchDB.Exec(`DELETE FROM notes_fts WHERE id = ?`, id)
+	if err := s.unindexNote(id); err != nil {
+		return err
+	}
+
+	// Delete file, unless the index still has another note there, as
+	// overwrites before writes checked for conflicts could leave behind
+	var shared int
+	if err := s.searchDB.QueryRow(`SELECT COUNT(*) FROM notes_meta WHERE filepath = ?`, filePath).Scan(&shared); err != nil {
+		return err
+	}
+	if shared == 0 {
+		fullPath := filepath.Join(s.basePath, filePath)
+		if err := os.Remove(fullPath); err != nil {
+			return err
+		}
+	}
+	return s.record(audit.ActionDelete, note, before, "")
+}
+
+// Move relocates a single note to another category.
+func (s *FileStore) Move(id, category string) error {
+	if err := ValidateCategory(category); err != nil {
+		return err
+	}
+
+	var filePath string
+	err := s.searchDB.QueryRow(`SELECT filepath FROM notes_fts WHERE id = ?`, id).Scan(&filePath)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("note %w: %s", ErrNotFound, id)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Delete file
-	fullPath := filepath.Join(s.basePath, filePath)
-	return os.Remove(fullPath)
+	return s.relocate([]relocation{{id: id, oldPath: filePath, category: CleanCategory(category)}})
+}
+
+// MoveCategory relocates every note in src and its descendants to dst,
+// keeping the subcategory structure. If dst already exists the two categories
+// are merged.
+func (s *FileStore) MoveCategory(src, dst string) error {
+	if err := ValidateCategory(dst); err != nil {
+		return err
+	}
+
+	src, dst = CleanCategory(src), CleanCategory(dst)
+	if src == dst {
+		return fmt.Errorf("%w: source and destination category are the same: %s", ErrConflict, src)
+	}
+	if IsInCategory(dst, src) {
+		return fmt.Errorf("%w: cannot move category %s into its own subcategory %s", ErrConflict, src, dst)
+	}
+
+	filter, args := categoryFilter(src)
+	rows, err := s.searchDB.Query(`SELECT id, filepath, category FROM notes_fts WHERE `+filter, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var moves []relocation
+	for rows.Next() {
+		var id, filePath, category string
+		if err := rows.Scan(&id, &filePath, &category); err != nil {
+			return err
+		}
+		moves = append(moves, relocation{id: id, oldPath: filePath, category: dst + category[len(src):]})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if len(moves) == 0 {
+		return fmt.Errorf("category %w: %s", ErrNotFound, src)
+	}
+
+	return s.relocate(moves)
+}
+
+type relocation struct {
+	id       string
+	oldPath  string
+	category string
+}
+
+// relocate rewrites notes into their new categories. New files are written
+// and the index is updated in a single transaction before any old file is
+// removed, so a failure leaves the store unchanged.
+func (s *FileStore) relocate(moves []relocation) error {
+	tx, err := s.searchDB.Begin()
+	if err != nil {
+		return err
+	}
+
+	var written, moved []string
+	var entries []audit.Entry
+	fail := func(err error) error {
+		tx.Rollback()
+		for _, p := range written {
+			os.Remove(p)
+		}
+		return err
+	}
+
+	now := time.Now()
+	for _, m := range moves {
+		note, err := s.parseMarkdownFile(filepath.Join(s.basePath, m.oldPath))
+		if err != nil {
+			return fail(fmt.Errorf("failed to read note %s: %w", m.id, err))
+		}
+
+		categoryPath := s.categoryPath(m.category)
+		if err := os.MkdirAll(categoryPath, 0755); err != nil {
+			return fail(fmt.Errorf("failed to create category directory: %w", err))
+		}
+
+		newPath := filepath.Join(categoryPath, slugify(note.Title)+".md")
+		if newPath == filepath.Join(s.basePath, m.oldPath) {
+			continue
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			return fail(fmt.Errorf("%w: note already exists in %s: %s", ErrConflict, m.category, note.Title))
+		}
+
+		before, _ := s.storedBody(m.oldPath)
+		note.Category = m.category
+		note.Updated = now
+		body, err := s.sealNote(note)
+		if err != nil {
+			return fail(err)
+		}
+		content, err := FormatNote(note, body)
+		if err != nil {
+			return fail(fmt.Errorf("failed to format markdown: %w", err))
+		}
+		if err := os.WriteFile(newPath, []byte(content), 0644); err != nil {
+			return fail(fmt.Errorf("failed to write file: %w", err))
+		}
+		written = append(written, newPath)
+		moved = append(moved, filepath.Join(s.basePath, m.oldPath))
+		entries = append(entries, s.entry(audit.ActionMove, note, before, body))
+
+		relPath, _ := filepath.Rel(s.basePath, newPath)
+		// The content is cleared if moving into the category encrypted it
+		if _, err := tx.Exec(`UPDATE notes_fts SET category = ?, filepath = ?, content = ? WHERE id = ?`,
+			m.category, relPath, indexedContent(note), m.id); err != nil {
+			return fail(err)
+		}
+		if _, err := tx.Exec(`UPDATE notes_meta SET filepath = ?, updated = ? WHERE id = ?`, relPath, now.UnixNano(), m.id); err != nil {
+			return fail(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	// Old files are only removed once the index points at the new ones
+	for _, oldPath := range moved {
+		os.Remove(oldPath)
+		s.removeEmptyDirs(filepath.Dir(oldPath))
+	}
+
+	return s.audit.Append(entries...)
 }
 
 func (s *FileStore) Search(query string, category string, tags []string) ([]*models.Note, error) {
@@ -241,15 +728,22 @@ func (s *FileStore) Search(query string, category string, tags []string) ([]*mod
 	sqlQuery := `SELECT filepath, rank FROM notes_fts WHERE notes_fts MATCH ?`
 	args := []interface{}{query}
 
+	category = CleanCategory(category)
 	if category != "" {
-		sqlQuery += ` AND category = ?`
-		args = append(args, category)
+		filter, filterArgs := categoryFilter(category)
+		sqlQuery += ` AND ` + filter
+		args = append(args, filterArgs...)
 	}
 
 	sqlQuery += ` ORDER BY rank LIMIT 100`
 
 	rows, err := s.searchDB.Query(sqlQuery, args...)
 	if err != nil {
+		// FTS5 reports query syntax errors as generic SQL errors
+		var sqlErr *sqlite.Error
+		if errors.As(err, &sqlErr) && sqlErr.Code() == sqlite3.SQLITE_ERROR {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
 		return nil, err
 	}
 	defer rows.Close()
@@ -264,6 +758,9 @@ func (s *FileStore) Search(query string, category string, tags []string) ([]*mod
 
 		fullPath := filepath.Join(s.basePath, filePath)
 		note, err := s.parseMarkdownFile(fullPath)
+		if errors.Is(err, ErrBadKey) {
+			return nil, err
+		}
 		if err != nil {
 			continue
 		}
@@ -299,26 +796,18 @@ func (s *FileStore) GetCategories() ([]string, error) {
 }
 
 func (s *FileStore) GetTags() ([]string, error) {
-	// Get all unique tags from index
-	rows, err := s.searchDB.Query(`SELECT DISTINCT tags FROM notes_fts WHERE tags != ''`)
+	rows, err := s.searchDB.Query(`SELECT DISTINCT tag FROM note_tags ORDER BY tag`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	tagSet := make(map[string]bool)
+	var tags []string
 	for rows.Next() {
-		var tagsStr string
-		if err := rows.Scan(&tagsStr); err != nil {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			continue
 		}
-		for _, tag := range strings.Fields(tagsStr) {
-			tagSet[tag] = true
-		}
-	}
-
-	tags := make([]string, 0, len(tagSet))
-	for tag := range tagSet {
 		tags = append(tags, tag)
 	}
 
@@ -331,7 +820,9 @@ func (s *FileStore) Close() error {
 
 // Helper functions
 
-func (s *FileStore) formatMarkdown(note *models.Note) (string, error) {
+// FormatNote renders a note file from the note's metadata and body, the
+// content as stored (see sealNote).
+func FormatNote(note *models.Note, body string) (string, error) {
 	meta := NoteMeta{
 		ID:       note.ID,
 		Title:    note.Title,
@@ -339,6 +830,7 @@ func (s *FileStore) formatMarkdown(note *models.Note) (string, error) {
 		Updated:  note.Updated,
 		Tags:     note.Tags,
 		Category: note.Category,
+		Metadata: note.Metadata,
 	}
 
 	yamlBytes, err := yaml.Marshal(meta)
@@ -346,46 +838,94 @@ func (s *FileStore) formatMarkdown(note *models.Note) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprintf("---\n%s---\n\n%s\n", string(yamlBytes), note.Content), nil
+	return fmt.Sprintf("---\n%s---\n\n%s\n", string(yamlBytes), body), nil
 }
 
-func (s *FileStore) parseMarkdownFile(path string) (*models.Note, error) {
-	data, err := os.ReadFile(path)
+// ParseNote parses a note file. Encrypted content is left as stored.
+func ParseNote(content string) (*models.Note, error) {
+	front, body, err := splitMarkdown(content)
 	if err != nil {
 		return nil, err
 	}
 
-	content := string(data)
-
-	// Parse YAML frontmatter
-	if !strings.HasPrefix(content, "---\n") {
-		return nil, fmt.Errorf("invalid markdown format: missing frontmatter")
-	}
-
-	parts := strings.SplitN(content[4:], "\n---\n", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid markdown format: malformed frontmatter")
-	}
-
 	var meta NoteMeta
-	if err := yaml.Unmarshal([]byte(parts[0]), &meta); err != nil {
+	if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
 		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
 	}
 
 	note := &models.Note{
 		ID:       meta.ID,
 		Title:    meta.Title,
-		Content:  strings.TrimSpace(parts[1]),
+		Content:  body,
 		Tags:     meta.Tags,
 		Created:  meta.Created,
 		Updated:  meta.Updated,
 		Category: meta.Category,
-		Metadata: make(map[string]string),
+		Metadata: meta.Metadata,
+	}
+	if note.Metadata == nil {
+		note.Metadata = make(map[string]string)
+	}
+	return note, nil
+}
+
+// NoteFile returns the slash-separated path of a note's file relative to the
+// store directory.
+func NoteFile(note *models.Note) string {
+	return path.Join(CleanCategory(note.Category), slugify(note.Title)+".md")
+}
+
+func (s *FileStore) parseMarkdownFile(path string) (*models.Note, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := ParseNote(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a key the ciphertext is returned as the content, but a key
+	// that fails to decrypt it is an error, not a note to show
+	if isEncrypted(note) && isArmored(note.Content) {
+		plain, err := s.keys.open(note.ID, note.Content)
+		if err != nil && !errors.Is(err, ErrNoKey) {
+			return nil, err
+		}
+		if err == nil {
+			note.Content = plain
+		}
 	}
 
 	return note, nil
 }
 
+// splitMarkdown splits a note file into its YAML frontmatter and its body as
+// stored.
+func splitMarkdown(content string) (string, string, error) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", "", fmt.Errorf("invalid markdown format: missing frontmatter")
+	}
+
+	parts := strings.SplitN(content[4:], "\n---\n", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid markdown format: malformed frontmatter")
+	}
+	return parts[0], strings.TrimSpace(parts[1]), nil
+}
+
+// storedBody returns the body of the note file at relPath as stored, which
+// is ciphertext for encrypted notes.
+func (s *FileStore) storedBody(relPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.basePath, relPath))
+	if err != nil {
+		return "", err
+	}
+	_, body, err := splitMarkdown(string(data))
+	return body, err
+}
+
 func slugify(s string) string {
 	// Convert to lowercase
 	s = strings.ToLower(s)
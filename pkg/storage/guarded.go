@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// AnyActor in a list of writers lets everyone write.
+const AnyActor = "*"
+
+// WritePolicy lists who may write to which categories. It is read from
+// permissions.yaml in the store directory:
+//
+//	categories:
+//	  api-creds: [alice]          # only alice
+//	  clients/acme: [alice, claude]
+//	  archive: []                 # nobody
+//	  "*": [alice, claude]        # categories without a rule of their own
+//
+// A rule covers the category's subcategories, and the most specific rule
+// wins. Without a matching rule anyone may write.
+type WritePolicy struct {
+	Categories map[string][]string `yaml:"categories,omitempty"`
+}
+
+// LoadWritePolicy reads a write policy file. A missing file yields a policy
+// that lets anyone write anywhere.
+func LoadWritePolicy(path string) (WritePolicy, error) {
+	var policy WritePolicy
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to read write policy: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse write policy %s: %w", path, err)
+	}
+
+	cleaned := make(map[string][]string, len(policy.Categories))
+	for category, writers := range policy.Categories {
+		if category != AnyActor {
+			category = CleanCategory(category)
+		}
+		cleaned[category] = writers
+	}
+	policy.Categories = cleaned
+
+	return policy, nil
+}
+
+// Empty reports whether the policy has no rules.
+func (p WritePolicy) Empty() bool {
+	return len(p.Categories) == 0
+}
+
+// Allows reports whether actor may write to category.
+func (p WritePolicy) Allows(actor, category string) bool {
+	writers, ok := p.Categories[AnyActor]
+	best := -1
+	for c, w := range p.Categories {
+		if c == AnyActor || !IsInCategory(category, c) {
+			continue
+		}
+		if len(c) > best {
+			writers, ok, best = w, true, len(c)
+		}
+	}
+	if !ok {
+		return true
+	}
+
+	for _, w := range writers {
+		if w == AnyActor || (actor != "" && w == actor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guarded is implemented by stores that restrict writes, so callers making
+// several changes can check them all before making the first.
+type Guarded interface {
+	// CheckWrite returns an ErrForbidden error unless the note with id, or
+	// a new note if id is empty, may be written to category
+	CheckWrite(id, category string) error
+}
+
+// GuardedStore wraps a store, rejecting changes with ErrForbidden when it is
+// read-only or the write policy doesn't let the actor write to the
+// categories involved. Reads pass through.
+type GuardedStore struct {
+	Store
+	readOnly bool
+	policy   WritePolicy
+	actor    string
+}
+
+// NewGuardedStore returns store restricted to reads if readOnly is set, and
+// otherwise to the writes policy allows actor.
+func NewGuardedStore(store Store, readOnly bool, policy WritePolicy, actor string) *GuardedStore {
+	return &GuardedStore{Store: store, readOnly: readOnly, policy: policy, actor: actor}
+}
+
+// allow checks a write to each of categories.
+func (s *GuardedStore) allow(categories ...string) error {
+	if s.readOnly {
+		return fmt.Errorf("%w: store is read-only", ErrForbidden)
+	}
+	for _, category := range categories {
+		if !s.policy.Allows(s.actor, category) {
+			if s.actor == "" {
+				return fmt.Errorf("%w: no actor set to write to %s (use --actor or BRAINDUMP_ACTOR)", ErrForbidden, category)
+			}
+			return fmt.Errorf("%w: %s may not write to %s", ErrForbidden, s.actor, category)
+		}
+	}
+	return nil
+}
+
+// allowNote checks a write to the note with id, currently stored in some
+// category, that ends up in category.
+func (s *GuardedStore) allowNote(id, category string) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	current, err := s.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.allow(current.Category, CleanCategory(category))
+}
+
+func (s *GuardedStore) CheckWrite(id, category string) error {
+	if id == "" {
+		return s.allow(CleanCategory(category))
+	}
+	return s.allowNote(id, category)
+}
+
+func (s *GuardedStore) Add(note *models.Note) error {
+	if err := s.allow(CleanCategory(note.Category)); err != nil {
+		return err
+	}
+	return s.Store.Add(note)
+}
+
+func (s *GuardedStore) Update(note *models.Note) error {
+	if err := s.allowNote(note.ID, note.Category); err != nil {
+		return err
+	}
+	return s.Store.Update(note)
+}
+
+func (s *GuardedStore) Delete(id string) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	note, err := s.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.allow(note.Category); err != nil {
+		return err
+	}
+	return s.Store.Delete(id)
+}
+
+func (s *GuardedStore) Move(id, category string) error {
+	if err := s.allowNote(id, category); err != nil {
+		return err
+	}
+	return s.Store.Move(id, category)
+}
+
+// MoveCategory checks every category the notes move out of and into.
+func (s *GuardedStore) MoveCategory(src, dst string) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	src, dst = CleanCategory(src), CleanCategory(dst)
+	notes, err := s.Store.List(src)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		if err := s.allow(note.Category, dst+strings.TrimPrefix(note.Category, src)); err != nil {
+			return err
+		}
+	}
+	return s.Store.MoveCategory(src, dst)
+}
+
+func (s *GuardedStore) RenameTag(oldTag, newTag string) (int, error) {
+	if err := s.allowTagged(oldTag); err != nil {
+		return 0, err
+	}
+	return s.Store.RenameTag(oldTag, newTag)
+}
+
+func (s *GuardedStore) DeleteTag(tag string) (int, error) {
+	if err := s.allowTagged(tag); err != nil {
+		return 0, err
+	}
+	return s.Store.DeleteTag(tag)
+}
+
+// NormalizeTags may change a note in any category, so it needs write access
+// to all of them.
+func (s *GuardedStore) NormalizeTags() (int, error) {
+	if err := s.allow(); err != nil {
+		return 0, err
+	}
+	counts, err := s.Store.CategoryCounts()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.allow(sortedKeys(counts)...); err != nil {
+		return 0, err
+	}
+	return s.Store.NormalizeTags()
+}
+
+// allowTagged checks a write to every note carrying tag.
+func (s *GuardedStore) allowTagged(tag string) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	notes, err := s.Store.Tagged(tag, 0)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		if err := s.allow(note.Category); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuditLogs returns the logs of the wrapped store.
+func (s *GuardedStore) AuditLogs() []*audit.Log {
+	if a, ok := s.Store.(Audited); ok {
+		return a.AuditLogs()
+	}
+	return nil
+}
+
+// RecordReveals logs reveals with the wrapped store.
+func (s *GuardedStore) RecordReveals(notes []*models.Note, command string) error {
+	return recordReveals(s.Store, notes, command)
+}
+
+// Seal seals note with the wrapped store.
+func (s *GuardedStore) Seal(note *models.Note) (string, error) {
+	if sealer, ok := s.Store.(Sealer); ok {
+		return sealer.Seal(note)
+	}
+	return note.Content, nil
+}
+
+// Unseal decrypts note with the wrapped store.
+func (s *GuardedStore) Unseal(note *models.Note) error {
+	if sealer, ok := s.Store.(Sealer); ok {
+		return sealer.Unseal(note)
+	}
+	return nil
+}
+
+// Import checks the categories of the notes written and of the notes they
+// replace.
+func (s *GuardedStore) Import(notes []*models.Note, replace []string) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	for _, note := range notes {
+		if err := s.allow(CleanCategory(note.Category)); err != nil {
+			return err
+		}
+	}
+	for _, id := range replace {
+		current, err := s.Store.Get(id)
+		if err != nil {
+			return err
+		}
+		if err := s.allow(current.Category); err != nil {
+			return err
+		}
+	}
+	return ImportNotes(s.Store, notes, replace)
+}
+
+// CheckWrite checks the store holding the note, or the store new notes are
+// added to for a new note.
+func (s *LayeredStore) CheckWrite(id, category string) error {
+	store, _ := s.target()
+	if id != "" {
+		var err error
+		if store, err = s.holder(id); err != nil {
+			return err
+		}
+	}
+	if g, ok := store.(Guarded); ok {
+		return g.CheckWrite(id, category)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MohGanji/braindump/pkg/audit"
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// Importer is implemented by stores that can write many notes at once.
+type Importer interface {
+	// Import removes the notes with the IDs in replace, then writes notes.
+	// The index is changed in a single transaction, and a failure leaves
+	// the store unchanged.
+	Import(notes []*models.Note, replace []string) error
+}
+
+// Import writes notes with one index transaction, which is much faster than
+// adding them one by one. A note replacing one with the same ID is logged as
+// an update, other replaced notes as deletions.
+func (s *FileStore) Import(notes []*models.Note, replace []string) error {
+	for _, note := range notes {
+		if err := ValidateCategory(note.Category); err != nil {
+			return fmt.Errorf("note %s: %w", note.ID, err)
+		}
+		note.Tags = s.tagPolicy.Normalize(note.Tags)
+		note.Category = CleanCategory(note.Category)
+		if err := s.canWrite(note); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.searchDB.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Files overwritten in place are restored if the import fails
+	var written []string
+	saved := make(map[string][]byte)
+	fail := func(err error) error {
+		tx.Rollback()
+		for _, p := range written {
+			if data, ok := saved[p]; ok {
+				os.WriteFile(p, data, 0644)
+			} else {
+				os.Remove(p)
+			}
+		}
+		return err
+	}
+
+	type removal struct {
+		note   *models.Note
+		path   string
+		before string
+	}
+	removed := make(map[string]removal)
+	freed := make(map[string]bool)
+	if len(replace) > 0 {
+		// notes_fts can only be searched by ID with a full scan, so the rows
+		// to delete are found in a single pass
+		wanted := make(map[string]bool, len(replace))
+		for _, id := range replace {
+			wanted[id] = true
+		}
+		rows, err := tx.Query(`SELECT rowid, id, filepath FROM notes_fts`)
+		if err != nil {
+			return fail(err)
+		}
+		rowids := make(map[string]int64, len(replace))
+		paths := make(map[string]string, len(replace))
+		for rows.Next() {
+			var rowid int64
+			var id, relPath string
+			if err := rows.Scan(&rowid, &id, &relPath); err != nil {
+				rows.Close()
+				return fail(err)
+			}
+			if wanted[id] {
+				rowids[id], paths[id] = rowid, relPath
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fail(err)
+		}
+
+		for _, id := range replace {
+			relPath, ok := paths[id]
+			if !ok {
+				continue
+			}
+			note, err := s.parseMarkdownFile(filepath.Join(s.basePath, relPath))
+			if err != nil {
+				note = &models.Note{ID: id}
+			}
+			before, _ := s.storedBody(relPath)
+			removed[id] = removal{note: note, path: filepath.Join(s.basePath, relPath), before: before}
+			freed[filepath.Join(s.basePath, relPath)] = true
+
+			if _, err := tx.Exec(`DELETE FROM notes_fts WHERE rowid = ?`, rowids[id]); err != nil {
+				return fail(err)
+			}
+			for _, table := range []string{"note_tags", "notes_meta"} {
+				if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id = ?`, id); err != nil {
+					return fail(err)
+				}
+			}
+		}
+	}
+
+	ix, err := prepareIndexer(tx)
+	if err != nil {
+		return fail(err)
+	}
+	defer ix.close()
+	exists, err := tx.Prepare(`SELECT COUNT(*) FROM notes_meta WHERE id = ?`)
+	if err != nil {
+		return fail(err)
+	}
+	defer exists.Close()
+
+	var entries []audit.Entry
+	kept := make(map[string]bool)
+	updated := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, note := range notes {
+		if _, ok := removed[note.ID]; !ok {
+			var n int
+			if err := exists.QueryRow(note.ID).Scan(&n); err != nil {
+				return fail(err)
+			}
+			if n > 0 {
+				return fail(fmt.Errorf("%w: note already exists: %s", ErrConflict, note.ID))
+			}
+		}
+
+		categoryPath := s.categoryPath(note.Category)
+		if !dirs[categoryPath] {
+			if err := os.MkdirAll(categoryPath, 0755); err != nil {
+				return fail(fmt.Errorf("failed to create category directory: %w", err))
+			}
+			dirs[categoryPath] = true
+		}
+
+		body, err := s.sealNote(note)
+		if err != nil {
+			return fail(err)
+		}
+		content, err := FormatNote(note, body)
+		if err != nil {
+			return fail(fmt.Errorf("failed to format markdown: %w", err))
+		}
+
+		filePath := filepath.Join(categoryPath, slugify(note.Title)+".md")
+		if kept[filePath] {
+			return fail(fmt.Errorf("%w: two imported notes in %s are titled %s", ErrConflict, note.Category, note.Title))
+		}
+		if data, err := os.ReadFile(filePath); err == nil {
+			if !freed[filePath] {
+				return fail(fmt.Errorf("%w: note already exists in %s: %s", ErrConflict, note.Category, note.Title))
+			}
+			saved[filePath] = data
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			return fail(fmt.Errorf("failed to write file: %w", err))
+		}
+		written = append(written, filePath)
+		kept[filePath] = true
+
+		relPath, _ := filepath.Rel(s.basePath, filePath)
+		if err := ix.index(note, relPath); err != nil {
+			return fail(err)
+		}
+
+		if old, ok := removed[note.ID]; ok {
+			entries = append(entries, s.entry(audit.ActionUpdate, note, old.before, body))
+			updated[note.ID] = true
+		} else {
+			entries = append(entries, s.entry(audit.ActionAdd, note, "", body))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	// Replaced files are only removed once the index no longer points at
+	// them, unless a new note was written in their place
+	for _, id := range replace {
+		old, ok := removed[id]
+		if !ok {
+			continue
+		}
+		if !updated[id] {
+			entries = append(entries, s.entry(audit.ActionDelete, old.note, old.before, ""))
+		}
+		if !kept[old.path] {
+			os.Remove(old.path)
+			s.removeEmptyDirs(filepath.Dir(old.path))
+		}
+	}
+
+	return s.audit.Append(entries...)
+}
+
+// ImportNotes imports notes into store in one batch if it is an Importer,
+// and else one note at a time.
+func ImportNotes(store Store, notes []*models.Note, replace []string) error {
+	if importer, ok := store.(Importer); ok {
+		return importer.Import(notes, replace)
+	}
+	for _, id := range replace {
+		if err := store.Delete(id); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	for _, note := range notes {
+		if err := store.Add(note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import writes notes replacing global notes to the global store, notes
+// replacing project notes to the project store and new notes to the store
+// selected by SetAddScope, each in one batch.
+func (s *LayeredStore) Import(notes []*models.Note, replace []string) error {
+	var globalReplace, projectReplace []string
+	inGlobal := make(map[string]bool)
+	inProject := make(map[string]bool)
+	for _, id := range replace {
+		store, err := s.holder(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if store == s.global {
+			globalReplace = append(globalReplace, id)
+			inGlobal[id] = true
+		} else {
+			projectReplace = append(projectReplace, id)
+			inProject[id] = true
+		}
+	}
+
+	var globalNotes, projectNotes []*models.Note
+	for _, note := range notes {
+		if inGlobal[note.ID] || (s.addGlobal && !inProject[note.ID]) {
+			globalNotes = append(globalNotes, note)
+		} else {
+			projectNotes = append(projectNotes, note)
+		}
+	}
+
+	if len(globalNotes) > 0 || len(globalReplace) > 0 {
+		if err := ImportNotes(s.global, globalNotes, globalReplace); err != nil {
+			return err
+		}
+	}
+	if err := ImportNotes(s.project, projectNotes, projectReplace); err != nil {
+		return err
+	}
+	label(projectNotes, ScopeProject)
+	label(globalNotes, ScopeGlobal)
+	return nil
+}
@@ -0,0 +1,387 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+// Scopes of notes read through a LayeredStore.
+const (
+	ScopeProject = "project"
+	ScopeGlobal  = "global"
+)
+
+// LayeredStore layers a project store over a global one. Reads merge both,
+// with project notes first and winning when both stores hold the same ID.
+// New notes go to the project store, unless SetAddScope selects the global
+// one; changes to existing notes go to the store that holds them. Notes read
+// through it are labeled with their Scope.
+type LayeredStore struct {
+	project Store
+	global  Store
+	// addGlobal sends new notes to the global store
+	addGlobal bool
+}
+
+// NewLayeredStore returns a store reading from project and global and
+// writing new notes to project.
+func NewLayeredStore(project, global Store) *LayeredStore {
+	return &LayeredStore{project: project, global: global}
+}
+
+// SetAddScope selects the store new notes are added and imported to,
+// ScopeProject (the default) or ScopeGlobal.
+func (s *LayeredStore) SetAddScope(scope string) {
+	s.addGlobal = scope == ScopeGlobal
+}
+
+// target returns the store new notes go to and its scope.
+func (s *LayeredStore) target() (Store, string) {
+	if s.addGlobal {
+		return s.global, ScopeGlobal
+	}
+	return s.project, ScopeProject
+}
+
+func label(notes []*models.Note, scope string) []*models.Note {
+	for _, n := range notes {
+		n.Scope = scope
+	}
+	return notes
+}
+
+// merge concatenates project and global notes, dropping global notes whose
+// ID is also in the project store.
+func merge(project, global []*models.Note) []*models.Note {
+	seen := make(map[string]bool, len(project))
+	notes := label(project, ScopeProject)
+	for _, n := range notes {
+		seen[n.ID] = true
+	}
+	for _, n := range label(global, ScopeGlobal) {
+		if !seen[n.ID] {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// byUpdated sorts merged notes most recently updated first, the order of
+// Recent and Tagged, and caps them at limit if it is above zero.
+func byUpdated(notes []*models.Note, limit int) []*models.Note {
+	sort.SliceStable(notes, func(i, j int) bool {
+		if !notes[i].Updated.Equal(notes[j].Updated) {
+			return notes[i].Updated.After(notes[j].Updated)
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	if limit > 0 && len(notes) > limit {
+		notes = notes[:limit]
+	}
+	return notes
+}
+
+// holder returns the store containing the note with id.
+func (s *LayeredStore) holder(id string) (Store, error) {
+	if _, err := s.project.Get(id); err == nil {
+		return s.project, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if _, err := s.global.Get(id); err != nil {
+		return nil, err
+	}
+	return s.global, nil
+}
+
+func (s *LayeredStore) Add(note *models.Note) error {
+	store, scope := s.target()
+	if err := store.Add(note); err != nil {
+		return err
+	}
+	note.Scope = scope
+	return nil
+}
+
+func (s *LayeredStore) Get(id string) (*models.Note, error) {
+	note, err := s.project.Get(id)
+	if err == nil {
+		note.Scope = ScopeProject
+		return note, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	note, err = s.global.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	note.Scope = ScopeGlobal
+	return note, nil
+}
+
+func (s *LayeredStore) GetByTitle(category, title string) (*models.Note, error) {
+	note, err := s.project.GetByTitle(category, title)
+	if err == nil {
+		note.Scope = ScopeProject
+		return note, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	note, err = s.global.GetByTitle(category, title)
+	if err != nil {
+		return nil, err
+	}
+	note.Scope = ScopeGlobal
+	return note, nil
+}
+
+func (s *LayeredStore) List(category string) ([]*models.Note, error) {
+	project, err := s.project.List(category)
+	if err != nil {
+		return nil, err
+	}
+	global, err := s.global.List(category)
+	if err != nil {
+		return nil, err
+	}
+	return merge(project, global), nil
+}
+
+func (s *LayeredStore) Recent(limit int) ([]*models.Note, error) {
+	project, err := s.project.Recent(limit)
+	if err != nil {
+		return nil, err
+	}
+	global, err := s.global.Recent(limit)
+	if err != nil {
+		return nil, err
+	}
+	return byUpdated(merge(project, global), limit), nil
+}
+
+func (s *LayeredStore) Update(note *models.Note) error {
+	store, err := s.holder(note.ID)
+	if err != nil {
+		return err
+	}
+	return store.Update(note)
+}
+
+func (s *LayeredStore) Delete(id string) error {
+	store, err := s.holder(id)
+	if err != nil {
+		return err
+	}
+	return store.Delete(id)
+}
+
+func (s *LayeredStore) Move(id, category string) error {
+	store, err := s.holder(id)
+	if err != nil {
+		return err
+	}
+	return store.Move(id, category)
+}
+
+// MoveCategory moves the category in each store that has it.
+func (s *LayeredStore) MoveCategory(src, dst string) error {
+	found := false
+	var notFound error
+	for _, store := range []Store{s.project, s.global} {
+		err := store.MoveCategory(src, dst)
+		if errors.Is(err, ErrNotFound) {
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return notFound
+	}
+	return nil
+}
+
+func (s *LayeredStore) Search(query string, category string, tags []string) ([]*models.Note, error) {
+	project, err := s.project.Search(query, category, tags)
+	if err != nil {
+		return nil, err
+	}
+	global, err := s.global.Search(query, category, tags)
+	if err != nil {
+		return nil, err
+	}
+	return merge(project, global), nil
+}
+
+func (s *LayeredStore) GetCategories() ([]string, error) {
+	counts, err := s.CategoryCounts()
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(counts), nil
+}
+
+// CategoryCounts counts the notes of both stores, leaving out global notes
+// shadowed by a project note with the same ID.
+func (s *LayeredStore) CategoryCounts() (map[string]int, error) {
+	counts, err := sumCounts(s.project.CategoryCounts, s.global.CategoryCounts)
+	if err != nil {
+		return nil, err
+	}
+	shadowed, err := s.shadowed()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range shadowed {
+		uncount(counts, n.Category)
+	}
+	return counts, nil
+}
+
+func (s *LayeredStore) GetTags() ([]string, error) {
+	counts, err := s.TagCounts()
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(counts), nil
+}
+
+// TagCounts counts the notes of both stores per tag, leaving out global
+// notes shadowed by a project note with the same ID.
+func (s *LayeredStore) TagCounts() (map[string]int, error) {
+	counts, err := sumCounts(s.project.TagCounts, s.global.TagCounts)
+	if err != nil {
+		return nil, err
+	}
+	shadowed, err := s.shadowed()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range shadowed {
+		seen := make(map[string]bool)
+		for _, tag := range n.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				uncount(counts, tag)
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (s *LayeredStore) Tagged(tag string, limit int) ([]*models.Note, error) {
+	project, err := s.project.Tagged(tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	global, err := s.global.Tagged(tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	return byUpdated(merge(project, global), limit), nil
+}
+
+func (s *LayeredStore) RenameTag(oldTag, newTag string) (int, error) {
+	return s.eachTagged(func(store Store) (int, error) { return store.RenameTag(oldTag, newTag) })
+}
+
+func (s *LayeredStore) DeleteTag(tag string) (int, error) {
+	return s.eachTagged(func(store Store) (int, error) { return store.DeleteTag(tag) })
+}
+
+func (s *LayeredStore) NormalizeTags() (int, error) {
+	project, err := s.project.NormalizeTags()
+	if err != nil {
+		return project, err
+	}
+	global, err := s.global.NormalizeTags()
+	return project + global, err
+}
+
+// eachTagged applies a tag change to both stores. The tag only counts as
+// not found if neither store has it.
+func (s *LayeredStore) eachTagged(change func(store Store) (int, error)) (int, error) {
+	total := 0
+	found := false
+	var notFound error
+	for _, store := range []Store{s.project, s.global} {
+		changed, err := change(store)
+		if errors.Is(err, ErrNotFound) {
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return total + changed, err
+		}
+		total += changed
+		found = true
+	}
+	if !found {
+		return 0, notFound
+	}
+	return total, nil
+}
+
+func (s *LayeredStore) Close() error {
+	return errors.Join(s.project.Close(), s.global.Close())
+}
+
+func sumCounts(sources ...func() (map[string]int, error)) (map[string]int, error) {
+	total := make(map[string]int)
+	for _, source := range sources {
+		counts, err := source()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range counts {
+			total[k] += v
+		}
+	}
+	return total, nil
+}
+
+// shadowed returns the global notes hidden by a project note with the same
+// ID. Project stores are small, so this looks up each project note.
+func (s *LayeredStore) shadowed() ([]*models.Note, error) {
+	project, err := s.project.List("")
+	if err != nil {
+		return nil, err
+	}
+	var notes []*models.Note
+	for _, n := range project {
+		note, err := s.global.Get(n.ID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// uncount takes one off the count of key, dropping it at zero.
+func uncount(counts map[string]int, key string) {
+	if counts[key]--; counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/MohGanji/braindump/pkg/models"
+)
+
+func newLayeredStore(t *testing.T) (*LayeredStore, *FileStore, *FileStore) {
+	t.Helper()
+	dir := t.TempDir()
+	project, err := NewFileStore(filepath.Join(dir, "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	global, err := NewFileStore(filepath.Join(dir, "global"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewLayeredStore(project, global)
+	t.Cleanup(func() { s.Close() })
+	return s, project, global
+}
+
+func TestLayeredCountsSkipShadowedNotes(t *testing.T) {
+	s, project, global := newLayeredStore(t)
+
+	shared := models.NewNote("ops", "Runbook", "global copy", []string{"ops", "oncall"})
+	if err := global.Add(shared); err != nil {
+		t.Fatal(err)
+	}
+	shadow := *shared
+	shadow.Content = "project copy"
+	if err := project.Add(&shadow); err != nil {
+		t.Fatal(err)
+	}
+	if err := global.Add(models.NewNote("ops", "Deploys", "global only", []string{"ops"})); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := s.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	categories, err := s.CategoryCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if categories["ops"] != len(notes) || len(notes) != 2 {
+		t.Errorf("ops counts %d notes, List returns %d, want 2", categories["ops"], len(notes))
+	}
+
+	tags, err := s.TagCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["ops"] != 2 || tags["oncall"] != 1 {
+		t.Errorf("tag counts %v, want ops:2 oncall:1", tags)
+	}
+}
+
+func TestLayeredAddScope(t *testing.T) {
+	s, project, global := newLayeredStore(t)
+
+	note := models.NewNote("ops", "Project note", "content", nil)
+	if err := s.Add(note); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := project.Get(note.ID); err != nil || note.Scope != ScopeProject {
+		t.Errorf("default add went to scope %q: %v", note.Scope, err)
+	}
+
+	s.SetAddScope(ScopeGlobal)
+	note = models.NewNote("ops", "Global note", "content", nil)
+	if err := s.Add(note); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := global.Get(note.ID); err != nil || note.Scope != ScopeGlobal {
+		t.Errorf("global add went to scope %q: %v", note.Scope, err)
+	}
+
+	notes, err := s.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 {
+		t.Errorf("List returned %d notes, want both stores' 2", len(notes))
+	}
+}
+
+func TestLayeredCheckWriteNewNotes(t *testing.T) {
+	dir := t.TempDir()
+	project, err := NewFileStore(filepath.Join(dir, "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	global, err := NewFileStore(filepath.Join(dir, "global"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewLayeredStore(project, NewGuardedStore(global, true, WritePolicy{}, ""))
+	defer s.Close()
+
+	if err := s.CheckWrite("", "ops"); err != nil {
+		t.Errorf("new project note: %v", err)
+	}
+	s.SetAddScope(ScopeGlobal)
+	if err := s.CheckWrite("", "ops"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("new note in the read-only global store: got %v, want ErrForbidden", err)
+	}
+}
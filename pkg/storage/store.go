@@ -9,10 +9,19 @@ type Store interface {
 	Get(id string) (*models.Note, error)
 	GetByTitle(category, title string) (*models.Note, error)
 	List(category string) ([]*models.Note, error)
+	Recent(limit int) ([]*models.Note, error)
 	Update(note *models.Note) error
 	Delete(id string) error
+	Move(id, category string) error
+	MoveCategory(src, dst string) error
 	Search(query string, category string, tags []string) ([]*models.Note, error)
 	GetCategories() ([]string, error)
+	CategoryCounts() (map[string]int, error)
 	GetTags() ([]string, error)
+	TagCounts() (map[string]int, error)
+	Tagged(tag string, limit int) ([]*models.Note, error)
+	RenameTag(oldTag, newTag string) (int, error)
+	DeleteTag(tag string) (int, error)
+	NormalizeTags() (int, error)
 	Close() error
 }
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MohGanji/braindump/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// TagPolicy controls how tags are normalized on add and update.
+//
+// It is read from tags.yaml in the store directory:
+//
+//	preserve_case: false
+//	aliases:
+//	  payments: payment
+//	  pay: payment
+type TagPolicy struct {
+	// PreserveCase disables lowercasing of tags
+	PreserveCase bool `yaml:"preserve_case,omitempty"`
+	// Aliases maps a tag to its canonical form
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+}
+
+// LoadTagPolicy reads a tag policy file. A missing file yields the default
+// policy (lowercase, no aliases).
+func LoadTagPolicy(path string) (TagPolicy, error) {
+	var policy TagPolicy
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to read tag policy: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse tag policy %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// WithDefaults returns p with the aliases of defaults added where p has
+// none for the same tag. Case is preserved if either policy preserves it.
+func (p TagPolicy) WithDefaults(defaults TagPolicy) TagPolicy {
+	merged := TagPolicy{
+		PreserveCase: p.PreserveCase || defaults.PreserveCase,
+		Aliases:      make(map[string]string, len(p.Aliases)+len(defaults.Aliases)),
+	}
+	for alias, canonical := range defaults.Aliases {
+		merged.Aliases[alias] = canonical
+	}
+	for alias, canonical := range p.Aliases {
+		merged.Aliases[alias] = canonical
+	}
+	return merged
+}
+
+// ApplyTagDefaults adds defaults to the store's tag policy for the settings
+// its tags.yaml doesn't make.
+func (s *FileStore) ApplyTagDefaults(defaults TagPolicy) {
+	s.tagPolicy = s.tagPolicy.WithDefaults(defaults)
+}
+
+// Normalize trims, lowercases and resolves aliases, dropping empty and
+// duplicate tags while keeping the original order.
+func (p TagPolicy) Normalize(tags []string) []string {
+	if len(tags) == 0 {
+		return tags
+	}
+
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = p.normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+func (p TagPolicy) normalizeTag(tag string) string {
+	tag = p.clean(tag)
+	for alias, canonical := range p.Aliases {
+		if strings.EqualFold(p.clean(alias), tag) {
+			return p.clean(canonical)
+		}
+	}
+	return tag
+}
+
+func (p TagPolicy) clean(tag string) string {
+	tag = strings.Join(strings.Fields(tag), " ")
+	if !p.PreserveCase {
+		tag = strings.ToLower(tag)
+	}
+	return tag
+}
+
+// TagCounts returns the number of notes carrying each tag.
+func (s *FileStore) TagCounts() (map[string]int, error) {
+	rows, err := s.searchDB.Query(`SELECT tag, COUNT(DISTINCT id) FROM note_tags GROUP BY tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, err
+		}
+		counts[tag] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// Tagged returns up to limit notes carrying tag, ignoring case, most
+// recently updated first. A limit of zero or less returns all of them.
+func (s *FileStore) Tagged(tag string, limit int) ([]*models.Note, error) {
+	if limit <= 0 {
+		limit = -1 // no limit in SQLite
+	}
+	return s.queryNotes(`
+		SELECT m.filepath FROM notes_meta m
+		WHERE m.id IN (SELECT id FROM note_tags WHERE lower(tag) = lower(?))
+		ORDER BY m.updated DESC, m.id
+		LIMIT ?
+	`, tag, limit)
+}
+
+// RenameTag replaces oldTag with newTag on every note that carries it. If
+// newTag is already in use the two tags are merged. It returns the number of
+// notes changed.
+func (s *FileStore) RenameTag(oldTag, newTag string) (int, error) {
+	if strings.TrimSpace(newTag) == "" {
+		return 0, fmt.Errorf("new tag must not be empty")
+	}
+
+	return s.rewriteTagged(oldTag, func(note *models.Note) {
+		for i, tag := range note.Tags {
+			if strings.EqualFold(tag, oldTag) {
+				note.Tags[i] = newTag
+			}
+		}
+	})
+}
+
+// DeleteTag removes tag from every note that carries it. It returns the
+// number of notes changed.
+func (s *FileStore) DeleteTag(tag string) (int, error) {
+	return s.rewriteTagged(tag, func(note *models.Note) {
+		var kept []string
+		for _, t := range note.Tags {
+			if !strings.EqualFold(t, tag) {
+				kept = append(kept, t)
+			}
+		}
+		note.Tags = kept
+	})
+}
+
+// NormalizeTags re-applies the tag policy to every note, for notes written
+// before the policy existed or changed. It returns the number of notes changed.
+func (s *FileStore) NormalizeTags() (int, error) {
+	notes, err := s.List("")
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, note := range notes {
+		normalized := s.tagPolicy.Normalize(note.Tags)
+		if strings.Join(normalized, "\x00") == strings.Join(note.Tags, "\x00") {
+			continue
+		}
+		note.Updated = time.Now()
+		if err := s.Update(note); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+func (s *FileStore) rewriteTagged(tag string, rewrite func(note *models.Note)) (int, error) {
+	rows, err := s.searchDB.Query(`SELECT DISTINCT id FROM note_tags WHERE lower(tag) = lower(?)`, tag)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("tag %w: %s", ErrNotFound, tag)
+	}
+
+	for i, id := range ids {
+		note, err := s.Get(id)
+		if err != nil {
+			return i, err
+		}
+		rewrite(note)
+		note.Updated = time.Now()
+		if err := s.Update(note); err != nil {
+			return i, err
+		}
+	}
+
+	return len(ids), nil
+}